@@ -67,6 +67,9 @@ var (
 	rpsToken      = env.Int("RATELIMIT_TOKEN", 0)       // reqs/sec
 	rpsRemoteAddr = env.Int("RATELIMIT_REMOTE_ADDR", 0) // reqs/sec
 	indexTxs      = env.Bool("INDEX_TRANSACTIONS", true)
+	headerSigning = env.Bool("HEADER_ONLY_SIGNING", false)  // requires signers that set SERVE_HEADER_SIGNING
+	serveHeaders  = env.Bool("SERVE_HEADER_SIGNING", false) // sign headers without validating their txs
+	blockReads    = env.Int("MAX_BLOCK_READS", 0)           // concurrent get-blocks queries; 0 means no limit
 	home          = config.HomeDirFromEnvironment()
 
 	version string // initialized in init()
@@ -351,9 +354,14 @@ func launchConfiguredCore(ctx context.Context, confOpts *config.Options, sdb *si
 		opts = append(opts, core.RateLimit(limit.RemoteAddrID, 2*(*rpsRemoteAddr), *rpsRemoteAddr))
 	}
 	// If the Core is configured as a block signer, add the sign-block RPC handler.
+	// The sign-block-header handler signs blocks whose transactions the signer
+	// never sees, so it's only added if the operator opts in.
 	if conf.IsSigner {
 		localSigner = initializeLocalSigner(ctx, confOpts, conf, db, c, processID, httpClient)
 		opts = append(opts, core.BlockSigner(localSigner.ValidateAndSignBlock))
+		if *serveHeaders {
+			opts = append(opts, core.BlockHeaderSigner(localSigner.ValidateAndSignBlockHeader))
+		}
 	}
 
 	// The Core is either configured as a generator or not. If it's configured
@@ -374,6 +382,8 @@ func launchConfiguredCore(ctx context.Context, confOpts *config.Options, sdb *si
 		c.MaxIssuanceWindow = bc.MillisDuration(conf.MaxIssuanceWindowMs)
 
 		gen := generator.New(c, signers, db)
+		gen.HeaderOnlySigning = *headerSigning
+		gen.MaxConcurrentBlockReads = *blockReads
 		opts = append(opts, core.GeneratorLocal(gen))
 	} else {
 		opts = append(opts, core.GeneratorRemote(&rpc.Client{
@@ -440,6 +450,15 @@ func remoteSignerInfo(ctx context.Context, processID, blockchainID string, conf
 	return a
 }
 
+// Both local and remote signers are used interchangeably
+// by the generator.
+var (
+	_ generator.KeyedSigner  = (*blocksigner.BlockSigner)(nil)
+	_ generator.RemoteSigner = (*remoteSigner)(nil)
+	_ generator.KeyedSigner  = (*remoteSigner)(nil)
+	_ generator.HeaderSigner = (*remoteSigner)(nil)
+)
+
 // remoteSigner defines the address and public key of another Core
 // that may sign blocks produced by this generator.
 type remoteSigner struct {
@@ -449,13 +468,29 @@ type remoteSigner struct {
 
 func (s *remoteSigner) SignBlock(ctx context.Context, marshalledBlock []byte) (signature []byte, err error) {
 	err = s.Client.Call(ctx, "/rpc/signer/sign-block", string(marshalledBlock), &signature)
-	return
+	if e, ok := errors.Root(err).(rpc.ErrStatusCode); ok && e.StatusCode == http.StatusForbidden && e.ErrorData != nil && e.ErrorData.ChainCode == "CH186" {
+		return nil, errors.WithDetail(generator.ErrPolicyRejected, e.ErrorData.Detail)
+	}
+	return signature, errors.Wrapf(err, "requesting signature from %s", s.Client.BaseURL)
+}
+
+func (s *remoteSigner) SignBlockHeader(ctx context.Context, marshalledHeader []byte) (signature []byte, err error) {
+	err = s.Client.Call(ctx, "/rpc/signer/sign-block-header", string(marshalledHeader), &signature)
+	return signature, errors.Wrapf(err, "requesting header signature from %s", s.Client.BaseURL)
 }
 
 func (s *remoteSigner) String() string {
 	return s.Client.BaseURL
 }
 
+func (s *remoteSigner) URL() string {
+	return s.Client.BaseURL
+}
+
+func (s *remoteSigner) PublicKey() ed25519.PublicKey {
+	return s.Key
+}
+
 func logWriter() io.Writer {
 	dropmsg := []byte("\nlog data dropped\n")
 	rotation := &errlog{w: rotation.Create(logFile, *logSize, *logCount)}
@@ -76,6 +76,7 @@ type API struct {
 	leader          leaderProcess
 	addr            string
 	signer          func(context.Context, *legacy.Block) ([]byte, error)
+	headerSigner    func(context.Context, *legacy.BlockHeader) ([]byte, error)
 	requestLimits   []requestLimit
 	generator       *generator.Generator
 	replicator      *fetch.Replicator
@@ -164,9 +165,13 @@ func (a *API) buildHandler() {
 		return a.submitter.Submit(ctx, tx)
 	}))
 	m.Handle(crosscoreRPCPrefix+"get-block", needConfig(a.getBlockRPC))
+	m.Handle(crosscoreRPCPrefix+"get-blocks", needConfig(a.getBlocksRPC))
 	m.Handle(crosscoreRPCPrefix+"get-snapshot-info", needConfig(a.getSnapshotInfoRPC))
 	m.Handle(crosscoreRPCPrefix+"get-snapshot", http.HandlerFunc(a.getSnapshotRPC))
 	m.Handle(crosscoreRPCPrefix+"signer/sign-block", needConfig(a.leaderSignHandler(a.signer)))
+	m.Handle(crosscoreRPCPrefix+"signer/sign-block-header", needConfig(a.leaderSignHeaderHandler(a.headerSigner)))
+	m.Handle(crosscoreRPCPrefix+"generator/make-block", needConfig(a.makeBlockRPC))
+	m.Handle(crosscoreRPCPrefix+"generator/debug-config", needConfig(a.debugConfigRPC))
 	m.Handle(crosscoreRPCPrefix+"block-height", needConfig(func(ctx context.Context) map[string]uint64 {
 		h := a.chain.Height()
 		return map[string]uint64{
@@ -371,6 +376,20 @@ func (a *API) leaderSignHandler(f func(context.Context, *legacy.Block) ([]byte,
 	}
 }
 
+func (a *API) leaderSignHeaderHandler(f func(context.Context, *legacy.BlockHeader) ([]byte, error)) func(context.Context, *legacy.BlockHeader) ([]byte, error) {
+	return func(ctx context.Context, bh *legacy.BlockHeader) ([]byte, error) {
+		if f == nil {
+			return nil, errNotFound
+		}
+		if a.leader.State() == leader.Leading {
+			return f(ctx, bh)
+		}
+		var resp []byte
+		err := a.forwardToLeader(ctx, "/rpc/signer/sign-block-header", bh, &resp)
+		return resp, err
+	}
+}
+
 // forwardToLeader forwards the current request to the core's leader
 // process. It relies on a.httpClient's TLS configuration for authenticating
 // with the leader cored. The internal policy must be authorized for the
@@ -1,10 +1,12 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	"chain/database/pg/pgtest"
 	"chain/errors"
 	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
 	"chain/protocol/prottest"
 	"chain/protocol/vm"
 	"chain/testutil"
@@ -198,6 +201,63 @@ func TestMux(t *testing.T) {
 	api.buildHandler()
 }
 
+func TestSignBlockHeaderRoute(t *testing.T) {
+	header, err := json.Marshal(&legacy.BlockHeader{Version: 1, Height: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signHeader := func(api *API) int {
+		api.buildHandler()
+		req := httptest.NewRequest("POST", crosscoreRPCPrefix+"signer/sign-block-header", bytes.NewReader(header))
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// A signer that hasn't opted in to header signing
+	// doesn't serve the route, even though it signs blocks.
+	var signed bool
+	api := &API{config: &config.Config{}, mux: http.NewServeMux(), leader: alwaysLeader{}}
+	BlockSigner(func(context.Context, *legacy.Block) ([]byte, error) { return nil, nil })(api)
+	if code := signHeader(api); code != http.StatusNotFound {
+		t.Errorf("sign-block-header without BlockHeaderSigner: status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	api = &API{config: &config.Config{}, mux: http.NewServeMux(), leader: alwaysLeader{}}
+	BlockHeaderSigner(func(context.Context, *legacy.BlockHeader) ([]byte, error) {
+		signed = true
+		return []byte{1}, nil
+	})(api)
+	if code := signHeader(api); code != http.StatusOK || !signed {
+		t.Errorf("sign-block-header with BlockHeaderSigner: status = %d, signed = %t, want %d, true", code, signed, http.StatusOK)
+	}
+}
+
+func TestDebugConfigRoute(t *testing.T) {
+	debugConfig := func(api *API) (int, map[string]interface{}) {
+		api.buildHandler()
+		req := httptest.NewRequest("POST", crosscoreRPCPrefix+"generator/debug-config", nil)
+		rec := httptest.NewRecorder()
+		api.ServeHTTP(rec, req)
+		var cfg map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &cfg)
+		return rec.Code, cfg
+	}
+
+	api := &API{config: &config.Config{}, mux: http.NewServeMux(), leader: alwaysLeader{}}
+	if code, _ := debugConfig(api); code != http.StatusNotFound {
+		t.Errorf("debug-config without a generator: status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	g := generator.New(prottest.NewChain(t), nil, nil)
+	g.MaxTxPerBlock = 7
+	api = &API{config: &config.Config{}, mux: http.NewServeMux(), leader: alwaysLeader{}, generator: g}
+	code, cfg := debugConfig(api)
+	if code != http.StatusOK || cfg["max_tx_per_block"] != float64(7) {
+		t.Errorf("debug-config = %d, %v, want %d with max_tx_per_block 7", code, cfg, http.StatusOK)
+	}
+}
+
 func TestTransfer(t *testing.T) {
 	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
 	ctx := context.Background()
@@ -40,12 +40,16 @@ var policyByRoute = map[string][]string{
 	"/list-unspent-outputs":   {"client-readwrite", "client-readonly"},
 	"/reset":                  {"client-readwrite", "internal"},
 
-	crosscoreRPCPrefix + "submit":            {"crosscore", "crosscore-signblock"},
-	crosscoreRPCPrefix + "get-block":         {"crosscore", "crosscore-signblock"},
-	crosscoreRPCPrefix + "get-snapshot-info": {"crosscore", "crosscore-signblock"},
-	crosscoreRPCPrefix + "get-snapshot":      {"crosscore", "crosscore-signblock"},
-	crosscoreRPCPrefix + "signer/sign-block": {"internal", "crosscore-signblock"},
-	crosscoreRPCPrefix + "block-height":      {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "submit":                   {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "get-block":                {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "get-blocks":               {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "get-snapshot-info":        {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "get-snapshot":             {"crosscore", "crosscore-signblock"},
+	crosscoreRPCPrefix + "signer/sign-block":        {"internal", "crosscore-signblock"},
+	crosscoreRPCPrefix + "signer/sign-block-header": {"internal", "crosscore-signblock"},
+	crosscoreRPCPrefix + "generator/make-block":     {"internal"},
+	crosscoreRPCPrefix + "generator/debug-config":   {"internal"},
+	crosscoreRPCPrefix + "block-height":             {"crosscore", "crosscore-signblock"},
 
 	"/list-authorization-grants":  {"client-readwrite", "client-readonly", "internal"},
 	"/create-authorization-grant": {"client-readwrite", "internal"},
@@ -101,6 +101,15 @@ func TestAuthz(t *testing.T) {
 			"internal":            false,
 			"public":              false,
 		},
+		crosscoreRPCPrefix + "get-blocks": map[string]bool{
+			"client-readwrite":    false,
+			"client-readonly":     false,
+			"crosscore":           true,
+			"crosscore-signblock": true,
+			"monitoring":          false,
+			"internal":            false,
+			"public":              false,
+		},
 		crosscoreRPCPrefix + "signer/sign-block": map[string]bool{
 			"client-readwrite":    false,
 			"client-readonly":     false,
@@ -110,6 +119,24 @@ func TestAuthz(t *testing.T) {
 			"internal":            true,
 			"public":              false,
 		},
+		crosscoreRPCPrefix + "signer/sign-block-header": map[string]bool{
+			"client-readwrite":    false,
+			"client-readonly":     false,
+			"crosscore":           false,
+			"crosscore-signblock": true,
+			"monitoring":          false,
+			"internal":            true,
+			"public":              false,
+		},
+		crosscoreRPCPrefix + "generator/debug-config": map[string]bool{
+			"client-readwrite":    false,
+			"client-readonly":     false,
+			"crosscore":           false,
+			"crosscore-signblock": false,
+			"monitoring":          false,
+			"internal":            true,
+			"public":              false,
+		},
 		"/info": map[string]bool{
 			"client-readwrite":    true,
 			"client-readonly":     true,
@@ -17,6 +17,10 @@ import (
 // when a new consensus program is detected.
 var ErrConsensusChange = errors.New("consensus program has changed")
 
+// ErrBadHeader is returned from ValidateAndSignBlockHeader
+// when the block header doesn't follow the previous block.
+var ErrBadHeader = errors.New("block header doesn't follow the previous block")
+
 // ErrInvalidKey is returned from SignBlock when the
 // key specified on the Signer is invalid. It may be
 // not found by the mock HSM or not paired to a valid
@@ -112,6 +116,52 @@ func (s *BlockSigner) ValidateAndSignBlock(ctx context.Context, b *legacy.Block)
 	return sig, nil
 }
 
+// ValidateAndSignBlockHeader checks the given block header against
+// the previous block and, if it follows that block without changing
+// the consensus program, computes and returns a signature for it.
+// It is used as the httpjson handler for /rpc/signer/sign-block-header,
+// for generators with HeaderOnlySigning set.
+//
+// Unlike ValidateAndSignBlock, it can't validate the block's
+// transactions, which the header commits to only by their merkle
+// root; it trusts the generator for those. Like the other signing
+// methods, it refuses to sign a different block at the same height
+// as one it has signed before.
+func (s *BlockSigner) ValidateAndSignBlockHeader(ctx context.Context, bh *legacy.BlockHeader) ([]byte, error) {
+	if bh.Height < 2 {
+		return nil, errors.WithDetailf(ErrBadHeader, "height %d", bh.Height)
+	}
+	err := <-s.c.BlockSoonWaiter(ctx, bh.Height-1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "waiting for block at height %d", bh.Height-1)
+	}
+	prev, err := s.c.GetBlock(ctx, bh.Height-1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting block at height %d", bh.Height-1)
+	}
+	if bh.PreviousBlockHash != prev.Hash() {
+		return nil, errors.WithDetailf(ErrBadHeader, "previous block hash %x, block %d is %x", bh.PreviousBlockHash.Bytes(), prev.Height, prev.Hash().Bytes())
+	}
+	if bh.Version < prev.Version || bh.TimestampMS <= prev.TimestampMS {
+		return nil, errors.WithDetailf(ErrBadHeader, "version %d and timestamp %d don't follow block %d", bh.Version, bh.TimestampMS, prev.Height)
+	}
+	if !bytes.Equal(bh.ConsensusProgram, prev.ConsensusProgram) {
+		return nil, errors.Wrap(ErrConsensusChange)
+	}
+
+	b := &legacy.Block{BlockHeader: *bh}
+	err = lockBlockHeight(ctx, s.db, b)
+	if err != nil {
+		return nil, errors.Wrap(err, "lock block height")
+	}
+
+	sig, err := s.hsm.Sign(ctx, s.Pub, &b.BlockHeader)
+	if err != nil {
+		return nil, errors.Sub(ErrInvalidKey, err)
+	}
+	return sig, nil
+}
+
 // lockBlockHeight records a signer's intention to sign a given block
 // at a given height.  It's an error if a different block at the same
 // height has previously been signed.
@@ -125,3 +175,8 @@ func lockBlockHeight(ctx context.Context, db pg.DB, b *legacy.Block) error {
 	_, err := db.ExecContext(ctx, q, b.Height, b.Hash())
 	return err
 }
+
+// PublicKey returns the key s signs with.
+func (s *BlockSigner) PublicKey() ed25519.PublicKey {
+	return s.Pub
+}
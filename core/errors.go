@@ -8,6 +8,7 @@ import (
 	"chain/core/asset"
 	"chain/core/blocksigner"
 	"chain/core/config"
+	"chain/core/generator"
 	"chain/core/leader"
 	"chain/core/query"
 	"chain/core/query/filter"
@@ -88,6 +89,7 @@ var errorFormatter = httperror.Formatter{
 		config.ErrNoBlockHSMURL:        {400, "CH111", "Block HSM URL cannot be empty when configuring a non mockhsm signer"},
 		errNoClientTokens:              {400, "CH120", "Cannot enable client authentication with no client tokens"},
 		blocksigner.ErrConsensusChange: {400, "CH150", "Refuse to sign block with consensus change"},
+		blocksigner.ErrBadHeader:       {400, "CH151", "Block header doesn't follow the previous block"},
 		errMissingAddr:                 {400, "CH160", "Address is missing"},
 		errInvalidAddr:                 {400, "CH161", "Address is invalid"},
 		raft.ErrAddressNotAllowed:      {400, "CH162", "Address is not allowed"},
@@ -96,6 +98,14 @@ var errorFormatter = httperror.Formatter{
 		raft.ErrPeerUninitialized:      {400, "CH165", "Peer node is uninitialized"},
 		raft.ErrUnknownPeer:            {400, "CH166", "Unknown peer"},
 		config.ErrConfigOp:             {400, "CH170", "Invalid configuration operation"},
+		generator.ErrNoTxs:             {400, "CH180", "No pending transactions to put in a block"},
+		generator.ErrTxTooLarge:        {400, "CH181", "Transaction is too large"},
+		generator.ErrRateLimited:       {429, "CH182", "Transaction submission rate limit exceeded"},
+		generator.ErrMempoolFull:       {503, "CH183", "Too many pending transactions; try again soon"},
+		generator.ErrTooManyBlockReads: {503, "CH184", "Too many concurrent block requests; try again soon"},
+		generator.ErrObserver:          {400, "CH185", "This core doesn't generate blocks"},
+		generator.ErrPolicyRejected:    {403, "CH186", "Block rejected by signer policy"},
+		generator.ErrTxExpired:         {400, "CH187", "Transaction max time has passed"},
 
 		// Signers error namespace (2xx)
 		signers.ErrBadQuorum: {400, "CH200", "Quorum must be greater than 1 and less than or equal to the length of xpubs"},
@@ -0,0 +1,136 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"chain/crypto/ed25519"
+	chainjson "chain/encoding/json"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/vm/vmutil"
+)
+
+// An AuditRecord describes a block the generator produced.
+// Records are written to Generator.AuditSink as JSON, one per line.
+type AuditRecord struct {
+	Height     uint64           `json:"height"`
+	Hash       bc.Hash          `json:"hash"`
+	Timestamp  time.Time        `json:"timestamp"`
+	TxIDs      []bc.Hash        `json:"transaction_ids"`
+	Signatures []AuditSignature `json:"signatures"`
+
+	// Signature, if present, is an ed25519 signature by
+	// Generator.AuditKey of the JSON encoding of the record
+	// with Signature omitted.
+	Signature chainjson.HexBytes `json:"signature,omitempty"`
+}
+
+// An AuditSignature is a block signature and the key that made it.
+type AuditSignature struct {
+	Pubkey    chainjson.HexBytes `json:"pubkey"`
+	Signature chainjson.HexBytes `json:"signature"`
+}
+
+// NewAuditRecord returns the unsigned audit record of b, a signed
+// block whose predecessor is prevBlock.
+func NewAuditRecord(b, prevBlock *legacy.Block) (*AuditRecord, error) {
+	rec := &AuditRecord{
+		Height:     b.Height,
+		Hash:       b.Hash(),
+		Timestamp:  b.Time().UTC(),
+		TxIDs:      make([]bc.Hash, 0, len(b.Transactions)),
+		Signatures: []AuditSignature{},
+	}
+	for _, tx := range b.Transactions {
+		rec.TxIDs = append(rec.TxIDs, tx.ID)
+	}
+	if prevBlock == nil {
+		return rec, nil // the initial block has no signatures
+	}
+
+	pubkeys, _, err := vmutil.ParseBlockMultiSigProgram(prevBlock.ConsensusProgram)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing prevblock output script")
+	}
+	for _, sig := range b.Witness {
+		i := indexKey(pubkeys, BlockSigningHash(b).Bytes(), sig)
+		if i < 0 {
+			continue
+		}
+		rec.Signatures = append(rec.Signatures, AuditSignature{
+			Pubkey:    chainjson.HexBytes(pubkeys[i]),
+			Signature: sig,
+		})
+	}
+	return rec, nil
+}
+
+// Sign sets rec.Signature to a signature by key.
+func (rec *AuditRecord) Sign(key ed25519.PrivateKey) error {
+	msg, err := rec.signingMessage()
+	if err != nil {
+		return err
+	}
+	rec.Signature = ed25519.Sign(key, msg)
+	return nil
+}
+
+// Verify reports whether rec has a valid signature by pubkey.
+func (rec *AuditRecord) Verify(pubkey ed25519.PublicKey) bool {
+	if len(rec.Signature) == 0 {
+		return false
+	}
+	msg, err := rec.signingMessage()
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(pubkey, msg, rec.Signature)
+}
+
+func (rec *AuditRecord) signingMessage() ([]byte, error) {
+	unsigned := *rec
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// audit writes the audit record of b to g.AuditSink, if set.
+// A failure is returned only if g.AuditFatal is set;
+// otherwise it is logged.
+func (g *Generator) audit(ctx context.Context, b, prevBlock *legacy.Block) error {
+	if g.AuditSink == nil {
+		return nil
+	}
+	err := g.writeAudit(b, prevBlock)
+	if err != nil && !g.AuditFatal {
+		log.Printkv(ctx, log.KeyMessage, "writing audit record failed", log.KeyError, err)
+		return nil
+	}
+	return err
+}
+
+func (g *Generator) writeAudit(b, prevBlock *legacy.Block) error {
+	rec, err := NewAuditRecord(b, prevBlock)
+	if err != nil {
+		return errors.Wrap(err, "building audit record")
+	}
+	if g.AuditKey != nil {
+		err = rec.Sign(g.AuditKey)
+		if err != nil {
+			return errors.Wrap(err, "signing audit record")
+		}
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return errors.Wrap(err, "encoding audit record")
+	}
+	line = append(line, '\n')
+
+	g.auditMu.Lock()
+	defer g.auditMu.Unlock()
+	_, err = g.AuditSink.Write(line)
+	return errors.Wrap(err, "writing audit record")
+}
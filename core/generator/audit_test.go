@@ -0,0 +1,88 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/vm/vmutil"
+	"chain/testutil"
+)
+
+func TestAuditRecord(t *testing.T) {
+	pub1, priv1, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub2, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog, err := vmutil.BlockMultiSigProgram([]ed25519.PublicKey{pub1, pub2}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 1, ConsensusProgram: prog}}
+	b := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 2, TimestampMS: 1000}}
+	h := b.Hash()
+	b.Witness = [][]byte{ed25519.Sign(priv1, h.Bytes())}
+
+	var sink bytes.Buffer
+	auditPub, auditKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(nil, nil, nil)
+	g.AuditSink = &sink
+	g.AuditKey = auditKey
+	err = g.audit(context.Background(), b, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rec AuditRecord
+	err = json.Unmarshal(sink.Bytes(), &rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Height != 2 || rec.Hash != h {
+		t.Errorf("got record for block %d %x, want 2 %x", rec.Height, rec.Hash.Bytes(), h.Bytes())
+	}
+	if len(rec.Signatures) != 1 || !bytes.Equal(rec.Signatures[0].Pubkey, pub1) {
+		t.Errorf("got signatures %v, want one by %x", rec.Signatures, pub1)
+	}
+	if !rec.Verify(auditPub) {
+		t.Error("audit record signature doesn't verify")
+	}
+	rec.Height = 3
+	if rec.Verify(auditPub) {
+		t.Error("altered audit record signature verifies")
+	}
+}
+
+type failingWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+func TestAuditFatal(t *testing.T) {
+	b := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 1}}
+
+	g := New(nil, nil, nil)
+	g.AuditSink = failingWriter{}
+	err := g.audit(context.Background(), b, nil)
+	if err != nil {
+		t.Errorf("non-fatal audit failure: got error %v, want nil", err)
+	}
+
+	g.AuditFatal = true
+	err = g.audit(context.Background(), b, nil)
+	if errors.Root(err) != errWrite {
+		testutil.FatalErr(t, err)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"chain/crypto/ed25519"
@@ -18,132 +21,630 @@ import (
 	"chain/protocol/vm/vmutil"
 )
 
-// errTooFewSigners is returned when a block-signing attempt finds
+// ErrTooFewSigners is returned when a block-signing attempt finds
 // that not enough signers are configured for the number of
-// signatures required.
-var errTooFewSigners = errors.New("too few signers")
+// signatures required, and by UpdateSigners when it would leave
+// too few.
+var ErrTooFewSigners = errors.New("too few signers")
 
 var errDuplicateBlock = errors.New("generator already committed to a block at that height")
 
+// ErrNoTxs is returned by MakeBlock when there are no pending
+// transactions to put in a block.
+var ErrNoTxs = errors.New("no pending transactions")
+
+// A SignError is returned when a block can't get
+// enough valid signatures to be committed.
+type SignError struct {
+	Quorum   int           // number of signatures required
+	Obtained int           // number of valid signatures received
+	Failed   []SignerError // signers that finished without a signature
+}
+
+func (e *SignError) Error() string {
+	return fmt.Sprintf("got %d of %d needed signatures", e.Obtained, e.Quorum)
+}
+
+// A SignerError records why a block signer returned no signature.
+type SignerError struct {
+	Signer BlockSigner
+	Err    error // nil if the signer returned no error
+}
+
+// ErrBadSignature is returned when a block signer returns a
+// signature that doesn't verify with any of the block's consensus
+// keys. The block is not committed.
+var ErrBadSignature = errors.New("invalid block signature")
+
+// ErrPolicyRejected is returned when so many block signers refuse
+// to sign a block because of their own policies that it can't get
+// enough signatures. A BlockSigner reports such a refusal,
+// as opposed to a failure to sign, with an error whose root is
+// ErrPolicyRejected. The generator doesn't retry it or count it
+// toward the signer's circuit breaker.
+//
+// Over HTTP, a Chain Core signer refuses a block by policy with
+// status 403 Forbidden and an error response with code CH186,
+// whose detail gives the reason.
+var ErrPolicyRejected = errors.New("block rejected by signer policy")
+
+// ErrStaleBlock is returned when attempting to commit a block at
+// a height that has already been committed.
+var ErrStaleBlock = errors.New("block height already committed")
+
+// ErrClockDrift is returned when the next block's timestamp would
+// be more than MaxFutureDrift ahead of the clock, because the
+// latest block's timestamp is.
+var ErrClockDrift = errors.New("latest block is too far ahead of the clock")
+
+// ErrBadPendingBlock is returned when the pending block left by a
+// previous leader can't be applied to the current state. The
+// generator can't make progress until this is resolved, unless
+// the problem is a transaction it can drop; see
+// Generator.DropInvalidTxOnCommit.
+var ErrBadPendingBlock = errors.New("invalid pending block")
+
 var (
 	once    sync.Once
 	latency *metrics.RotatingLatency
 )
 
-func recordSince(t0 time.Time) {
+func recordLatency(d time.Duration) {
 	// Lazily publish the expvar and initialize the rotating latency
 	// histogram. We don't want to publish metrics that aren't meaningful.
 	once.Do(func() {
 		latency = metrics.NewRotatingLatency(5, 2*time.Second)
 		metrics.PublishLatency("generator.make_block", latency)
 	})
-	latency.RecordSince(t0)
+	latency.Record(d)
+}
+
+// MakeBlock immediately generates a new block from the pending
+// transaction pool, collects the required signatures and commits
+// the block to the blockchain. It returns the committed block.
+//
+// It is safe to call MakeBlock while Generate is running; the two
+// never produce blocks concurrently. Like Generate, MakeBlock must
+// only be called by the leader process. If there is no pending
+// block and no pending transactions, it returns ErrNoTxs.
+func (g *Generator) MakeBlock(ctx context.Context) (*legacy.Block, error) {
+	if err := g.checkProducer(); err != nil {
+		return nil, err
+	}
+	b, err := g.makeBlock(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrNoTxs
+	}
+	return b, nil
+}
+
+// AssembleBlock returns the block MakeBlock would make from the
+// current pending transactions, and the state snapshot that would
+// result, without signing or committing it. It doesn't change the
+// pending tx pool or the blockchain.
+func (g *Generator) AssembleBlock(ctx context.Context) (*legacy.Block, *state.Snapshot, error) {
+	if err := g.checkProducer(); err != nil {
+		return nil, nil, err
+	}
+	var txs []*legacy.Tx
+	if g.TxSource != nil {
+		pending, err := g.TxSource.Pending(ctx)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "getting pending txs")
+		}
+		txs, _ = g.splitTxs(pending)
+	} else {
+		g.mu.Lock()
+		txs, _ = g.splitTxs(g.pool)
+		txs = append([]*legacy.Tx(nil), txs...)
+		g.mu.Unlock()
+	}
+
+	latestBlock, latestSnapshot := g.latest()
+	t, err := g.blockTime(ctx, latestBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.prevalidateTxs(txs)
+	b, s, err := g.generateBlock(ctx, latestBlock, latestSnapshot, t, orderTxs(txs, g.TxOrdering))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generate")
+	}
+	return b, s, nil
 }
 
 // makeBlock generates a new legacy.Block, collects the required signatures
-// and commits the block to the blockchain.
-func (g *Generator) makeBlock(ctx context.Context) (err error) {
-	t0 := time.Now()
-	defer recordSince(t0)
+// and commits the block to the blockchain. It returns the committed
+// block, or nil if there was nothing to commit.
+//
+// Unless force is set, makeBlock observes MinTxPerBlock and
+// MaxEmptyBlockInterval. A pending block is always committed.
+func (g *Generator) makeBlock(ctx context.Context, force bool) (b *legacy.Block, err error) {
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
 
-	latestBlock, latestSnapshot := g.chain.State()
-	var b *legacy.Block
+	t0 := g.now()
+	defer func() { recordLatency(g.since(t0)) }()
+	defer func() {
+		if err != nil || b != nil {
+			g.recordBlockMade(err)
+		}
+	}()
+
+	latestBlock, latestSnapshot := g.latest()
 	var s *state.Snapshot
+	var txs []*legacy.Tx // taken from the pending txs
+
+	height := uint64(1)
+	if latestBlock != nil {
+		height = latestBlock.Height + 1
+	}
+	ctx, sp := g.startSpan(ctx, "generator.make_block", fmt.Sprintf("height %d", height))
+	defer func() { sp.finish(err) }()
 
 	// Check to see if we already have a pending, generated block.
 	// This can happen if the leader process exits between generating
 	// the block and committing the signed block to the blockchain.
-	b, err = getPendingBlock(ctx, g.db)
+	b, err = g.loadPendingBlock(ctx)
 	if err != nil {
-		return errors.Wrap(err, "retrieving the pending block")
+		return nil, errors.Wrap(err, "retrieving the pending block")
 	}
 	if b != nil && (latestBlock == nil || b.Height == latestBlock.Height+1) {
-		s = state.Copy(latestSnapshot)
-		err = s.ApplyBlock(legacy.MapBlock(b))
+		b, s, err = g.applyPendingBlock(ctx, b, latestBlock, latestSnapshot)
 		if err != nil {
-			log.Fatalkv(ctx, log.KeyError, err)
+			return nil, err
 		}
+	} else if latestBlock == nil {
+		return nil, ErrNotBootstrapped
 	} else {
-		g.mu.Lock()
-		txs := g.pool
-		g.pool = nil
-		g.poolHashes = make(map[bc.Hash]bool)
-		g.mu.Unlock()
+		var t time.Time
+		t, err = g.blockTime(ctx, latestBlock)
+		if err != nil {
+			return nil, err
+		}
+
+		var due, allowEmpty bool
+		txs, due, allowEmpty, err = g.nextTxs(ctx, latestBlock, force)
+		g.expireTxs(ctx)
+		if err != nil || !due {
+			return nil, err
+		}
 
-		b, s, err = g.chain.GenerateBlock(ctx, latestBlock, latestSnapshot, time.Now(), txs)
+		g.prevalidateTxs(txs)
+		b, s, err = g.generateBlock(ctx, latestBlock, latestSnapshot, t, orderTxs(txs, g.TxOrdering))
 		if err != nil {
-			return errors.Wrap(err, "generate")
+			return nil, errors.Wrap(err, "generate")
 		}
-		if len(b.Transactions) == 0 {
-			return nil // don't bother making an empty block
+		if len(b.Transactions) == 0 && !allowEmpty {
+			sp.printf("no block due")
+			return nil, nil // don't bother making an empty block
+		}
+		sp.printf("generated block with %d txs", len(b.Transactions))
+		err = g.storePendingBlock(ctx, b)
+		if err != nil {
+			return nil, errors.Wrap(err, "saving pending block")
 		}
-		err = savePendingBlock(ctx, g.db, b)
+	}
+	ctx = blockLogContext(ctx, b)
+	phases := &blockPhases{start: t0, assembled: g.now()}
+	err = g.signAndCommitBlock(ctx, b, s, latestBlock, phases)
+	if err != nil {
+		return nil, err
+	}
+	g.logSlowBlock(ctx, phases)
+	g.removeFromSource(ctx, b, txs)
+	g.recordBlock(b)
+	return b, nil
+}
+
+// nextTxs returns the pending txs for the next block after latest,
+// taking them out of the built-in pending tx pool, and whether a
+// block is due (always, if force is set) and may be empty.
+func (g *Generator) nextTxs(ctx context.Context, latest *legacy.Block, force bool) (txs []*legacy.Tx, due, allowEmpty bool, err error) {
+	if g.TxSource != nil {
+		pending, err := g.TxSource.Pending(ctx)
 		if err != nil {
-			return errors.Wrap(err, "saving pending block")
+			return nil, false, false, errors.Wrap(err, "getting pending txs")
+		}
+		if !force {
+			due, allowEmpty = g.blockDue(latest, len(pending))
+			if !due {
+				return nil, false, false, nil
+			}
+		}
+		txs, _ = g.splitTxs(pending)
+		return txs, true, allowEmpty, nil
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	due = true
+	if !force {
+		due, allowEmpty = g.blockDue(latest, len(g.pool))
+		if !due {
+			return nil, false, false, nil
+		}
+	}
+	txs = g.takeTxs()
+	g.recordPending()
+	return txs, true, allowEmpty, nil
+}
+
+// blockTime returns the timestamp for the block after prev.
+// It's the current time, or g.TimestampFunc's result if set,
+// unless that isn't after prev, such as after an NTP correction.
+// Then it's just after prev, so that block timestamps keep
+// increasing, and blockTime logs a warning. It returns
+// ErrClockDrift if the timestamp would be more than
+// MaxFutureDrift ahead of the clock.
+func (g *Generator) blockTime(ctx context.Context, prev *legacy.Block) (time.Time, error) {
+	now := g.now()
+	if prev == nil {
+		return now, nil
+	}
+	t := now
+	if g.TimestampFunc != nil {
+		t = g.TimestampFunc(prev)
+	}
+	behind := bc.Millis(t) <= prev.TimestampMS
+	if behind {
+		t = prev.Time().Add(time.Millisecond)
+	}
+	drift := t.Sub(now)
+	if g.MaxFutureDrift > 0 && drift > g.MaxFutureDrift {
+		return time.Time{}, errors.WithDetailf(ErrClockDrift, "block %d timestamp is %s ahead of the clock; the limit is %s", prev.Height+1, drift, g.MaxFutureDrift)
+	}
+	if behind {
+		log.Printkv(ctx, log.KeyMessage, "timestamp is not after the latest block; using a later block timestamp",
+			"height", prev.Height+1, "drift", drift)
+	}
+	return t, nil
+}
+
+// takeTxs removes and returns the transactions for the next block
+// from the pending tx pool, as chosen by splitTxs.
+// The caller must hold g.mu.
+func (g *Generator) takeTxs() []*legacy.Tx {
+	take, keep := g.splitTxs(g.pool)
+	if len(keep) == 0 {
+		g.pool = nil
+		g.poolTimes = make(map[bc.Hash]time.Time)
+		return take
+	}
+
+	g.pool = keep
+	for _, tx := range take {
+		delete(g.poolTimes, tx.ID)
+	}
+	return take
+}
+
+// splitTxs divides txs, which must be in topological order, into
+// the transactions for the next block and those that will remain
+// pending, observing MaxTxPerBlock and TxPriority. Both results
+// are in the order of txs.
+func (g *Generator) splitTxs(txs []*legacy.Tx) (take, keep []*legacy.Tx) {
+	n := g.MaxTxPerBlock
+	if n <= 0 || len(txs) <= n {
+		return txs, nil
+	}
+	if g.TxPriority == nil {
+		return txs[:n:n], append([]*legacy.Tx(nil), txs[n:]...)
+	}
+
+	chosen := selectByPriority(txs, n, g.TxPriority)
+	for i, tx := range txs {
+		if chosen[i] {
+			take = append(take, tx)
+		} else {
+			keep = append(keep, tx)
+		}
+	}
+	return take, keep
+}
+
+// selectByPriority chooses up to n of txs, which must be in
+// topological order, highest priority first, breaking ties by
+// position in txs. It never chooses a tx that spends an output of
+// another tx in txs unless it also chooses that tx.
+func selectByPriority(txs []*legacy.Tx, n int, priority func(*legacy.Tx) int) []bool {
+	prio := make([]int, len(txs))
+	order := make([]int, len(txs))
+	producer := make(map[bc.Hash]int)
+	for i, tx := range txs {
+		prio[i] = priority(tx)
+		order[i] = i
+		for _, id := range tx.ResultIds {
+			producer[*id] = i
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return prio[order[a]] > prio[order[b]] })
+
+	chosen := make([]bool, len(txs))
+	count := 0
+	for _, i := range order {
+		if count == n {
+			break
+		}
+		ok := true
+		for _, id := range txs[i].SpentOutputIDs {
+			if j, found := producer[id]; found && !chosen[j] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			chosen[i] = true
+			count++
 		}
 	}
-	return g.commitBlock(ctx, b, s, latestBlock)
+	return chosen
+}
+
+// blockDue reports whether it's time to make a new block on top of
+// latest, given n pending transactions. It also reports whether the
+// new block may be empty, which is only the case when the chain has
+// been idle for MaxEmptyBlockInterval.
+func (g *Generator) blockDue(latest *legacy.Block, n int) (due, allowEmpty bool) {
+	if g.MaxEmptyBlockInterval > 0 && latest != nil && g.since(latest.Time()) >= g.MaxEmptyBlockInterval {
+		return true, true
+	}
+	return n > 0 && n >= g.MinTxPerBlock, false
 }
 
+// commitBlock signs and commits b, which must be the next block
+// after prevBlock. The caller must hold g.makeMu, and ctx should
+// carry b's log fields; see blockLogContext.
 func (g *Generator) commitBlock(ctx context.Context, b *legacy.Block, s *state.Snapshot, prevBlock *legacy.Block) error {
+	return g.signAndCommitBlock(ctx, b, s, prevBlock, nil)
+}
+
+// signAndCommitBlock is commitBlock, also noting in phases, if
+// it's not nil, when b was signed.
+func (g *Generator) signAndCommitBlock(ctx context.Context, b *legacy.Block, s *state.Snapshot, prevBlock *legacy.Block, phases *blockPhases) error {
+	if latest, _ := g.latest(); latest != nil && b.Height <= latest.Height {
+		return errors.WithDetailf(ErrStaleBlock, "block height %d, blockchain height %d", b.Height, latest.Height)
+	}
+	for _, block := range []*legacy.Block{prevBlock, b} {
+		if err := g.checkBlockVersion(block); err != nil {
+			return err
+		}
+	}
+
 	err := g.getAndAddBlockSignatures(ctx, b, prevBlock)
 	if err != nil {
+		if ctx.Err() != nil {
+			log.Printkv(ctx, log.KeyMessage, "canceled before block was signed; leaving it pending")
+		}
 		return errors.Wrap(err, "sign")
 	}
+	phases.markSigned(g.now())
+	return g.commitSignedBlock(ctx, b, s, prevBlock)
+}
+
+// commitSignedBlock commits b, which must be the next block after
+// prevBlock and already signed. It's the rest of commitBlock.
+func (g *Generator) commitSignedBlock(ctx context.Context, b *legacy.Block, s *state.Snapshot, prevBlock *legacy.Block) (err error) {
+	// Once the block is signed, finish committing it even if ctx
+	// is canceled, so the next leader doesn't have to recover it.
+	// Committing the same block twice is harmless.
+	if ctx.Err() != nil {
+		log.Printkv(ctx, log.KeyMessage, "canceled after block was signed; committing it anyway")
+	}
+	parent := ctx
+	ctx = detachedContext{ctx}
+	ctx, sp := g.startSpan(ctx, "generator.commit_block", fmt.Sprintf("height %d", b.Height))
+	defer func() { sp.finish(err) }()
 
-	err = g.chain.CommitAppliedBlock(ctx, b, s)
+	err = g.audit(ctx, b, prevBlock)
+	if err != nil {
+		return errors.Wrap(err, "audit")
+	}
+	sp.printf("audited")
+
+	// The fencing check and the block insert are one statement,
+	// so a newer leader can't claim its epoch in between.
+	// Committing to the chain then stores the block again,
+	// which is a no-op.
+	err = g.saveFencedBlock(ctx, b)
+	if err != nil {
+		return err
+	}
+	s = g.compactSnapshot(ctx, b, s)
+	err = g.commitAppliedBlock(ctx, parent, b, s)
 	if err != nil {
 		return errors.Wrap(err, "commit")
 	}
+	sp.printf("committed")
+	g.advanceTip(b, s)
+	g.pruneCommitted(b)
+	g.forgetSubmitted(b.Transactions)
+
+	g.blockCommitted(ctx, b, s)
+
+	if g.SnapshotInterval > 0 && b.Height%g.SnapshotInterval == 0 {
+		// The block is committed already, so a checkpoint failure
+		// is only reported; the next checkpoint will cover it.
+		if err := g.chain.SaveSnapshot(ctx, b.Height, s); err != nil {
+			atomic.AddInt32(&g.checkpointFails, 1)
+			g.recordCheckpointFailure()
+			log.Printkv(ctx, log.KeyMessage, "failed to save snapshot checkpoint", log.KeyError, err)
+		} else {
+			atomic.StoreInt32(&g.checkpointFails, 0)
+			log.Printkv(ctx, log.KeyMessage, "saved snapshot checkpoint")
+		}
+	}
 	return nil
 }
 
-func (g *Generator) getAndAddBlockSignatures(ctx context.Context, b, prevBlock *legacy.Block) error {
+// commitAppliedBlock commits b and its resulting state s to the
+// blockchain, retrying failures up to g.CommitRetries times unless
+// parent is canceled. A retry is safe because committing the same
+// block again is a no-op, but it stops if another block has been
+// stored at b's height.
+func (g *Generator) commitAppliedBlock(ctx, parent context.Context, b *legacy.Block, s *state.Snapshot) error {
+	for attempt := 1; ; attempt++ {
+		err := g.chain.CommitAppliedBlock(ctx, b, s)
+		if err == nil || attempt > g.CommitRetries {
+			return err
+		}
+		if stored, getErr := g.chain.GetBlock(ctx, b.Height); getErr == nil && stored.Hash() != b.Hash() {
+			return errors.WithDetailf(ErrStaleBlock, "a different block was stored at height %d", b.Height)
+		}
+		log.Printkv(ctx, log.KeyMessage, "retrying block commit", "attempt", attempt, log.KeyError, err)
+		select {
+		case <-parent.Done():
+			return err
+		case <-time.After(retryBackoff(g.CommitRetryBackoff, attempt)):
+		}
+	}
+}
+
+// blockLogContext returns ctx with log fields identifying b,
+// for the log lines written while b is being committed.
+func blockLogContext(ctx context.Context, b *legacy.Block) context.Context {
+	return log.AddPrefixkv(ctx, "height", b.Height, "block_hash", fmt.Sprintf("%x", b.Hash().Bytes()))
+}
+
+// nextHeight returns the height of the next block to be made.
+func (g *Generator) nextHeight() uint64 {
+	latest, _ := g.latest()
+	if latest == nil {
+		return 1
+	}
+	return latest.Height + 1
+}
+
+// detachedContext carries the values of its Context,
+// but is never canceled and has no deadline.
+type detachedContext struct{ context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (g *Generator) getAndAddBlockSignatures(ctx context.Context, b, prevBlock *legacy.Block) (err error) {
 	if prevBlock == nil && b.Height == 1 {
 		return nil // no signatures needed for initial block
 	}
+	ctx, sp := g.startSpan(ctx, "generator.sign", fmt.Sprintf("height %d", b.Height))
+	defer func() { sp.finish(err) }()
 
 	pubkeys, quorum, err := vmutil.ParseBlockMultiSigProgram(prevBlock.ConsensusProgram)
 	if err != nil {
 		return errors.Wrap(err, "parsing prevblock output script")
 	}
-	if len(g.signers) < quorum {
-		return errTooFewSigners
+	signers, breakers, stats := g.signerState()
+	if len(signers) < quorum {
+		return ErrTooFewSigners
 	}
 
-	hashForSig := b.Hash()
+	hashForSig := BlockSigningHash(b)
 	marshalledBlock, err := b.MarshalText()
 	if err != nil {
 		return errors.Wrap(err, "marshalling block")
 	}
+	marshalledHeader, err := g.marshalHeader(b)
+	if err != nil {
+		return err
+	}
 
-	ctx, cancel := context.WithCancel(ctx)
+	parent := ctx
+	var cancel context.CancelFunc
+	if g.SigningTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, g.SigningTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
 
+	// Retries must not push signing past the next block period.
+	var retryDeadline time.Time
+	if p := g.Period(); p > 0 {
+		retryDeadline = g.now().Add(p)
+	}
+
 	goodSigs := make([][]byte, len(pubkeys))
-	replies := make([][]byte, len(g.signers))
-	done := make(chan int, len(g.signers))
-	for i, signer := range g.signers {
-		go getSig(ctx, signer, marshalledBlock, &replies[i], i, done)
+	replies := make([][]byte, len(signers))
+	replyErrs := make([]error, len(signers))
+	done := make(chan int, len(signers))
+	ask := func(order []int) {
+		for _, i := range order {
+			go g.getSig(ctx, signers[i], breakers[i], stats[i], marshalledBlock, marshalledHeader, retryDeadline, &replies[i], &replyErrs[i], i, done)
+		}
+	}
+
+	// With SignerHedgeDelay, ask the fastest quorum of signers
+	// first and hold the rest in reserve.
+	reserve := signerOrder(stats)
+	var hedge <-chan time.Time
+	if g.SignerHedgeDelay > 0 && quorum < len(signers) {
+		ask(reserve[:quorum])
+		reserve = reserve[quorum:]
+		t := time.NewTimer(g.SignerHedgeDelay)
+		defer t.Stop()
+		hedge = t.C
+	} else {
+		ask(reserve)
+		reserve = nil
+	}
+	askReserve := func() {
+		ask(reserve)
+		reserve, hedge = nil, nil
 	}
 
 	nready := 0
-	for i := 0; i < len(g.signers) && nready < quorum; i++ {
-		sig := replies[<-done]
+	rejected := 0 // by signer policy
+	var failed []SignerError
+gather:
+	for nreplies := 0; nreplies < len(signers) && nready < quorum && len(signers)-rejected >= quorum; {
+		var j int
+		select {
+		case j = <-done:
+			nreplies++
+		case <-hedge:
+			askReserve()
+			continue
+		case <-ctx.Done():
+			break gather
+		}
+		sig := replies[j]
 		if sig == nil {
+			failed = append(failed, SignerError{Signer: signers[j], Err: replyErrs[j]})
+			if errors.Root(replyErrs[j]) == ErrPolicyRejected {
+				rejected++
+			}
+			askReserve()
 			continue
 		}
 		k := indexKey(pubkeys, hashForSig.Bytes(), sig)
-		if k >= 0 && goodSigs[k] == nil {
+		if k < 0 {
+			log.Printkv(ctx, "error", "invalid signature", "signature", sig, "signer", signers[j])
+			return errors.Wrapf(ErrBadSignature, "from signer %v", signers[j])
+		}
+		if goodSigs[k] == nil {
 			goodSigs[k] = sig
 			nready++
-		} else if k < 0 {
-			log.Printkv(ctx, "error", "invalid signature", "block", b.Hash(), "signature", sig)
+		} else {
+			askReserve() // a duplicate key doesn't count toward quorum
 		}
 	}
 
+	sp.printf("got %d of %d needed signatures from %d signers", nready, quorum, len(signers))
+	atomic.StoreInt32(&g.signersReachable, int32(nready))
+	g.checkQuorumMargin(ctx, stats, quorum)
+	if nready < quorum && len(signers)-rejected < quorum {
+		return errors.WithDetailf(ErrPolicyRejected, "%d of %d signers refused block %d; %d signatures needed", rejected, len(signers), b.Height, quorum)
+	}
 	if nready < quorum {
-		return fmt.Errorf("got %d of %d needed signatures", nready, quorum)
+		err := error(&SignError{Quorum: quorum, Obtained: nready, Failed: failed})
+		if ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+			err = errors.Wrapf(err, "signing timed out after %s", g.SigningTimeout)
+		}
+		return err
 	}
 	b.Witness = nonNilSigs(goodSigs)
 	return nil
@@ -158,15 +659,81 @@ func indexKey(keys []ed25519.PublicKey, msg, sig []byte) int {
 	return -1
 }
 
-func getSig(ctx context.Context, signer BlockSigner, marshalledBlock []byte, sig *[]byte, i int, done chan int) {
+// getSig requests a signature from signer, retrying failed requests
+// up to g.SignerRetries times as long as the next attempt would start
+// before retryDeadline (if nonzero). It stores the signature in *sig,
+// or nil and the final error in *errp. It doesn't call signer at all
+// while br is tripped. It records each attempt in st. If
+// marshalledHeader isn't nil, a HeaderSigner gets it instead of
+// marshalledBlock.
+func (g *Generator) getSig(ctx context.Context, signer BlockSigner, br *breaker, st *signerStats, marshalledBlock, marshalledHeader []byte, retryDeadline time.Time, sig *[]byte, errp *error, i int, done chan int) {
+	ctx, sp := g.startSpan(ctx, "generator.sign_block", signerTitle(signer))
+	defer func() { sp.finish(*errp) }()
+
+	if !g.breakerAllow(br) {
+		*sig = nil
+		*errp = ErrSignerTripped
+		done <- i
+		return
+	}
+
 	var err error
-	*sig, err = signer.SignBlock(ctx, marshalledBlock)
-	if err != nil && ctx.Err() != context.Canceled {
-		log.Printkv(ctx, "error", err, "signer", signer)
+	for attempt := 1; ; attempt++ {
+		t0 := g.now()
+		*sig, err = requestSig(ctx, signer, marshalledBlock, marshalledHeader)
+		d := g.since(t0)
+		sp.printf("attempt %d took %s", attempt, d)
+		g.recordSignerLatency(signer, d)
+		st.record(g.now(), d, err, ctx.Err() != nil)
+		if errors.Root(err) == ErrPolicyRejected {
+			log.Printkv(ctx, log.KeyMessage, "block signer refused block by policy", "signer", signer, "reason", errors.Detail(err))
+			*sig = nil
+			*errp = err
+			g.breakerRecord(br, nil, false) // the signer is working
+			done <- i
+			return
+		}
+		if err == nil || attempt > g.SignerRetries {
+			break
+		}
+		wait := retryBackoff(g.SignerRetryBackoff, attempt)
+		if !retryDeadline.IsZero() && g.now().Add(wait).After(retryDeadline) {
+			break
+		}
+		log.Printkv(ctx, log.KeyMessage, "retrying block signer", "signer", signer, "attempt", attempt, log.KeyError, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	g.breakerRecord(br, err, ctx.Err() != nil)
+	if err != nil {
+		*sig = nil
+		*errp = err
+		if ctx.Err() != context.Canceled {
+			log.Printkv(ctx, "error", err, "signer", signer)
+		}
 	}
 	done <- i
 }
 
+// retryBackoff returns how long to wait before retry number n
+// (starting at 1). It doubles with each retry, plus random jitter
+// of up to the same amount again.
+func retryBackoff(base time.Duration, n int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if n > 16 {
+		n = 16 // cap the exponent to avoid overflow
+	}
+	d := base << uint(n-1)
+	return d + time.Duration(rand.Int63n(int64(d)))
+}
+
 func nonNilSigs(a [][]byte) (b [][]byte) {
 	for _, p := range a {
 		if p != nil {
@@ -176,7 +743,44 @@ func nonNilSigs(a [][]byte) (b [][]byte) {
 	return b
 }
 
+// loadPendingBlock returns the pending block, from g.db or, for a
+// generator without a database, from memory.
+func (g *Generator) loadPendingBlock(ctx context.Context) (*legacy.Block, error) {
+	if g.db != nil {
+		return getPendingBlock(ctx, g.db)
+	}
+	g.pendingMu.Lock()
+	defer g.pendingMu.Unlock()
+	if g.pendingBlock == nil {
+		return nil, nil
+	}
+	b := *g.pendingBlock // as if read from the database
+	return &b, nil
+}
+
+// storePendingBlock saves b as the pending block, like
+// savePendingBlock, in g.db or in memory.
+func (g *Generator) storePendingBlock(ctx context.Context, b *legacy.Block) error {
+	if g.db != nil {
+		return savePendingBlock(ctx, g.db, b)
+	}
+	g.pendingMu.Lock()
+	defer g.pendingMu.Unlock()
+	if g.pendingBlock != nil && g.pendingBlock.Height >= b.Height {
+		return errDuplicateBlock
+	}
+	g.pendingBlock = b
+	return nil
+}
+
 // getPendingBlock retrieves the generated, uncommitted block if it exists.
+//
+// There is at most one: generator_pending_block is keyed on a
+// singleton column, and savePendingBlock replaces the row only with
+// a higher block. The generator doesn't generate a block until the
+// one before it is committed, so a leader that exits at any point
+// leaves only its latest block pending, and recoverPendingBlock
+// handles one that's stale or ahead of the blockchain.
 func getPendingBlock(ctx context.Context, db pg.DB) (*legacy.Block, error) {
 	const q = `SELECT data FROM generator_pending_block`
 	var block legacy.Block
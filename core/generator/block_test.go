@@ -1,11 +1,25 @@
 package generator
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
 	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/protocol/prottest/memstore"
+	"chain/protocol/state"
+	"chain/testutil"
 )
 
 func TestSavePendingBlock(t *testing.T) {
@@ -28,11 +42,126 @@ func TestSavePendingBlock(t *testing.T) {
 		t.Errorf("got %s, want %s", err, errDuplicateBlock)
 	}
 
-	// Saving a higher block should succeed.
+	// Saving a higher block should succeed, and replace the
+	// pending block rather than adding another.
 	err = savePendingBlock(ctx, db, fakeBlock(101))
 	if err != nil {
 		t.Fatal(err)
 	}
+	var n int
+	err = db.QueryRowContext(ctx, `SELECT count(*) FROM generator_pending_block`).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("got %d pending blocks, want 1", n)
+	}
+	b, err := getPendingBlock(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Height != 101 {
+		t.Errorf("pending block height = %d, want 101", b.Height)
+	}
+}
+
+func TestBlockDue(t *testing.T) {
+	recent := fakeBlock(2)
+	recent.TimestampMS = bc.Millis(time.Now())
+	old := fakeBlock(2)
+	old.TimestampMS = bc.Millis(time.Now().Add(-time.Hour))
+
+	cases := []struct {
+		minTx      int
+		maxEmpty   time.Duration
+		latest     *legacy.Block
+		n          int
+		due, empty bool
+	}{
+		{0, 0, old, 0, false, false},
+		{0, 0, old, 1, true, false},
+		{5, 0, old, 4, false, false},
+		{5, 0, old, 5, true, false},
+		{5, time.Minute, recent, 4, false, false},
+		{5, time.Minute, old, 4, true, true},
+		{0, time.Minute, old, 0, true, true},
+	}
+	for i, c := range cases {
+		g := &Generator{MinTxPerBlock: c.minTx, MaxEmptyBlockInterval: c.maxEmpty}
+		due, empty := g.blockDue(c.latest, c.n)
+		if due != c.due || empty != c.empty {
+			t.Errorf("case %d: blockDue = %t, %t, want %t, %t", i, due, empty, c.due, c.empty)
+		}
+	}
+}
+
+func TestTakeTxsMaxTxPerBlock(t *testing.T) {
+	ctx := context.Background()
+	g := New(nil, nil, nil)
+	g.MaxTxPerBlock = 2
+
+	var txs []*legacy.Tx
+	for i := 0; i < 3; i++ {
+		tx := legacy.NewTx(legacy.TxData{Version: 1, MinTime: uint64(i)})
+		txs = append(txs, tx)
+		err := g.Submit(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g.mu.Lock()
+	got := g.takeTxs()
+	g.mu.Unlock()
+	if len(got) != 2 || got[0] != txs[0] || got[1] != txs[1] {
+		t.Errorf("takeTxs() took %d txs, want the oldest 2", len(got))
+	}
+	pending := g.PendingTxs()
+	if len(pending) != 1 || pending[0] != txs[2] {
+		t.Errorf("got %d pending txs, want only the newest", len(pending))
+	}
+
+	// A tx that went into a block can be submitted again.
+	errs, _ := g.SubmitBatch(ctx, []*legacy.Tx{txs[0], txs[2]})
+	if errs[0] != nil || errs[1] != ErrDuplicateTx {
+		t.Errorf("SubmitBatch errors = %v, want [<nil> %v]", errs, ErrDuplicateTx)
+	}
+}
+
+func TestTakeTxsPriority(t *testing.T) {
+	// d spends an output of a, so it can't be chosen without a.
+	out := bc.NewHash([32]byte{0xff})
+	a := testTx(1, []*bc.Hash{&out}, nil)
+	b := testTx(2, nil, nil)
+	c := testTx(3, nil, nil)
+	d := testTx(4, nil, []bc.Hash{out})
+	prio := map[*legacy.Tx]int{a: 0, b: 1, c: 2, d: 3}
+
+	g := New(nil, nil, nil)
+	g.MaxTxPerBlock = 2
+	g.TxPriority = func(tx *legacy.Tx) int { return prio[tx] }
+	g.pool = []*legacy.Tx{a, b, c, d}
+	for _, tx := range g.pool {
+		g.poolTimes[tx.ID] = time.Now()
+	}
+
+	got := g.takeTxs()
+	want := []*legacy.Tx{b, c}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("takeTxs() = %v, want %v", txIDs(got), txIDs(want))
+	}
+	if !reflect.DeepEqual(g.pool, []*legacy.Tx{a, d}) {
+		t.Errorf("pool after takeTxs = %v, want [1 4]", txIDs(g.pool))
+	}
+
+	// With the same priorities, ties go to the oldest.
+	prio = map[*legacy.Tx]int{}
+	g.pool = []*legacy.Tx{c, b, a}
+	got = g.takeTxs()
+	want = []*legacy.Tx{c, b}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("takeTxs() with equal priorities = %v, want %v", txIDs(got), txIDs(want))
+	}
 }
 
 func fakeBlock(height uint64) *legacy.Block {
@@ -40,3 +169,205 @@ func fakeBlock(height uint64) *legacy.Block {
 		BlockHeader: legacy.BlockHeader{Height: height},
 	}
 }
+
+func TestCommitBlockSnapshotInterval(t *testing.T) {
+	ctx := context.Background()
+	store := &snapshotStore{MemStore: memstore.New()}
+	c := prottest.NewChain(t, prottest.WithStore(store))
+	g := New(c, nil, nil)
+	g.SnapshotInterval = 3
+
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	for c.Height() < 4 {
+		prev, snapshot := c.State()
+		b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = g.commitBlock(ctx, b, s, prev)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if got := store.saved(b.Height); got != (b.Height == 3) {
+			t.Errorf("after committing block %d, snapshot saved = %v, want %v", b.Height, got, b.Height == 3)
+		}
+	}
+}
+
+func TestCommitBlockSnapshotFailure(t *testing.T) {
+	ctx := context.Background()
+	store := &snapshotStore{MemStore: memstore.New(), err: errors.New("disk full")}
+	c := prottest.NewChain(t, prottest.WithStore(store))
+	g := New(c, nil, nil)
+	g.SnapshotInterval = 1
+
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	prev, snapshot := c.State()
+	b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.commitBlock(ctx, b, s, prev)
+	if err != nil {
+		t.Errorf("commitBlock() with a failing checkpoint = %v, want nil", err)
+	}
+	if latest := g.LatestBlock(); latest.Hash() != b.Hash() {
+		t.Errorf("latest block is %d, want %d", latest.Height, b.Height)
+	}
+	hs, err := g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.CheckpointFailures != 1 {
+		t.Errorf("Health().CheckpointFailures = %d, want 1", hs.CheckpointFailures)
+	}
+
+	// A checkpoint that's saved resets the count.
+	store.mu.Lock()
+	store.err = nil
+	store.mu.Unlock()
+	prev, snapshot = c.State()
+	b, s, err = c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.commitBlock(ctx, b, s, prev)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	hs, err = g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.CheckpointFailures != 0 {
+		t.Errorf("Health().CheckpointFailures after a saved checkpoint = %d, want 0", hs.CheckpointFailures)
+	}
+}
+
+func TestCommitBlockLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	g.OnBlockCommit = func(context.Context, *legacy.Block, *state.Snapshot) error {
+		return errors.New("hook failed")
+	}
+
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	prev, snapshot := c.State()
+	b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.commitBlock(blockLogContext(ctx, b), b, s, prev)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	line := buf.String()
+	for _, want := range []string{"height=2", fmt.Sprintf("block_hash=%x", b.Hash().Bytes()), "block commit hook failed"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("log output %q doesn't contain %q", line, want)
+		}
+	}
+}
+
+func TestCommitBlockRetries(t *testing.T) {
+	ctx := context.Background()
+	store := &flakyStore{MemStore: memstore.New()}
+	c := prottest.NewChain(t, prottest.WithStore(store))
+	g := New(c, nil, nil)
+	g.CommitRetries = 2
+
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	commit := func(ctx context.Context, failures int) error {
+		prev, snapshot := c.State()
+		b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		store.failNext(failures)
+		return g.commitBlock(ctx, b, s, prev)
+	}
+
+	err := commit(ctx, 2)
+	if err != nil {
+		t.Errorf("commitBlock() with 2 failures and 2 retries = %v, want nil", err)
+	}
+	if h := c.Height(); h != 2 {
+		t.Errorf("chain height = %d, want 2", h)
+	}
+
+	err = commit(ctx, 3)
+	if errors.Root(err) != errSaveBlock {
+		t.Errorf("commitBlock() with 3 failures and 2 retries = %v, want %v", err, errSaveBlock)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	g.CommitRetryBackoff = time.Hour
+	err = commit(canceled, 1)
+	if errors.Root(err) != errSaveBlock {
+		t.Errorf("commitBlock() after cancel = %v, want %v", err, errSaveBlock)
+	}
+}
+
+var errSaveBlock = errors.New("save block failed")
+
+// flakyStore fails a given number of calls to SaveBlock.
+type flakyStore struct {
+	*memstore.MemStore
+	mu    sync.Mutex
+	fails int
+}
+
+func (s *flakyStore) failNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fails = n
+}
+
+func (s *flakyStore) SaveBlock(ctx context.Context, b *legacy.Block) error {
+	s.mu.Lock()
+	if s.fails > 0 {
+		s.fails--
+		s.mu.Unlock()
+		return errSaveBlock
+	}
+	s.mu.Unlock()
+	return s.MemStore.SaveBlock(ctx, b)
+}
+
+// snapshotStore records the heights of the snapshots it saves.
+type snapshotStore struct {
+	*memstore.MemStore
+	mu      sync.Mutex
+	heights map[uint64]bool
+	err     error // returned by SaveSnapshot, if set
+}
+
+func (s *snapshotStore) SaveSnapshot(ctx context.Context, height uint64, snapshot *state.Snapshot) error {
+	s.mu.Lock()
+	if s.heights == nil {
+		s.heights = make(map[uint64]bool)
+	}
+	s.heights[height] = true
+	err := s.err
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.MemStore.SaveSnapshot(ctx, height, snapshot)
+}
+
+func (s *snapshotStore) saved(height uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.heights[height]
+}
@@ -0,0 +1,35 @@
+package generator
+
+import (
+	"fmt"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// A BlockID identifies a block by its height and hash, for tools
+// that need a key for blocks.
+//
+// A block's hash alone is already unique: the header the hash
+// commits to includes the block's height and the hash of the
+// block before it. Block timestamps are unique too, since the
+// generator always makes each block's timestamp at least a
+// millisecond after its predecessor's, even if the clock hasn't
+// moved on; but they aren't a good key, because the blocks a
+// different generator made for another blockchain can share them.
+type BlockID struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// NewBlockID returns the BlockID of b.
+func NewBlockID(b *legacy.Block) BlockID {
+	return BlockID{Height: b.Height, Hash: b.Hash()}
+}
+
+// String returns the height and hash of id, in the form
+// "<height>-<hex hash>", which sorts by height for blocks of
+// the same number of digits.
+func (id BlockID) String() string {
+	return fmt.Sprintf("%d-%x", id.Height, id.Hash.Bytes())
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestBlockIDSameClockTick(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	prev, _ := c.State()
+	g.Clock = &fakeClock{now: prev.Time().Add(time.Second)}
+
+	var ids []BlockID
+	for i := 0; i < 2; i++ {
+		prev, snapshot := c.State()
+		ts, err := g.blockTime(ctx, prev)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		b, s, err := g.generateBlock(ctx, prev, snapshot, ts, nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = g.commitBlock(ctx, b, s, prev)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		ids = append(ids, NewBlockID(b))
+	}
+
+	b2, err := c.GetBlock(ctx, 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	b3, err := c.GetBlock(ctx, 3)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if b3.TimestampMS <= b2.TimestampMS {
+		t.Errorf("block 3 timestamp %d, want after block 2's %d", b3.TimestampMS, b2.TimestampMS)
+	}
+	if ids[0] == ids[1] || ids[0].Hash == ids[1].Hash || ids[0].String() == ids[1].String() {
+		t.Errorf("blocks made in the same clock tick have the same ID %v", ids[0])
+	}
+	if want := fmt.Sprintf("3-%x", b3.Hash().Bytes()); ids[1].String() != want {
+		t.Errorf("BlockID.String() = %s, want %s", ids[1], want)
+	}
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"encoding/json"
+	"time"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// BlockJSONVersion is the version of the BlockJSON format.
+// Fields may be added without changing it; it changes only if
+// existing fields are removed or change meaning.
+const BlockJSONVersion = 1
+
+// BlockJSON is a summary of a block for use in JSON APIs.
+// Hashes are encoded as hex strings. The canonical form of a
+// block is still its binary encoding; see legacy.Block.
+type BlockJSON struct {
+	FormatVersion     int       `json:"format_version"`
+	Height            uint64    `json:"height"`
+	Hash              bc.Hash   `json:"hash"`
+	PreviousBlockHash bc.Hash   `json:"previous_block_hash"`
+	Timestamp         time.Time `json:"timestamp"`
+	TxCount           int       `json:"transaction_count"`
+	TxIDs             []bc.Hash `json:"transaction_ids"`
+}
+
+// NewBlockJSON returns the BlockJSON summary of b.
+func NewBlockJSON(b *legacy.Block) *BlockJSON {
+	txIDs := make([]bc.Hash, 0, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		txIDs = append(txIDs, tx.ID)
+	}
+	return &BlockJSON{
+		FormatVersion:     BlockJSONVersion,
+		Height:            b.Height,
+		Hash:              b.Hash(),
+		PreviousBlockHash: b.PreviousBlockHash,
+		Timestamp:         b.Time().UTC(),
+		TxCount:           len(b.Transactions),
+		TxIDs:             txIDs,
+	}
+}
+
+// MarshalBlockJSON returns the JSON encoding of the BlockJSON
+// summary of b.
+func MarshalBlockJSON(b *legacy.Block) ([]byte, error) {
+	return json.Marshal(NewBlockJSON(b))
+}
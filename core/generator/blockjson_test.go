@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"fmt"
+	"testing"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+func TestMarshalBlockJSON(t *testing.T) {
+	tx := legacy.NewTx(legacy.TxData{Version: 1})
+	b := &legacy.Block{
+		BlockHeader: legacy.BlockHeader{
+			Version:           1,
+			Height:            7,
+			PreviousBlockHash: bc.NewHash([32]byte{1}),
+			TimestampMS:       1500000000000,
+		},
+		Transactions: []*legacy.Tx{tx},
+	}
+
+	got, err := MarshalBlockJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`{"format_version":1,"height":7,"hash":"%x","previous_block_hash":"01%062x","timestamp":"2017-07-14T02:40:00Z","transaction_count":1,"transaction_ids":["%x"]}`,
+		b.Hash().Bytes(), 0, tx.ID.Bytes())
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
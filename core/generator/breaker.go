@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"sync"
+	"time"
+
+	"chain/errors"
+)
+
+// ErrSignerTripped is reported for a block signer that the
+// generator isn't asking for signatures because it has failed
+// too many times in a row. See SignerBreakerThreshold.
+var ErrSignerTripped = errors.New("block signer circuit breaker open")
+
+// A breaker tracks consecutive failures of one block signer.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int       // consecutive failed requests
+	openUntil time.Time // no requests before this, once tripped
+	probing   bool      // a request is in flight after the cooldown
+}
+
+func newBreakers(n int) []*breaker {
+	b := make([]*breaker, n)
+	for i := range b {
+		b[i] = new(breaker)
+	}
+	return b
+}
+
+// breakerAllow reports whether to send a request to the signer
+// tracked by b. Once tripped, a signer gets a single probe request
+// each time its cooldown passes, until one succeeds.
+func (g *Generator) breakerAllow(b *breaker) bool {
+	if g.SignerBreakerThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < g.SignerBreakerThreshold {
+		return true
+	}
+	if b.probing || g.now().Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// breakerRecord records the result of a request allowed by
+// breakerAllow. Requests that were canceled don't count.
+func (g *Generator) breakerRecord(b *breaker, err error, canceled bool) {
+	if g.SignerBreakerThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	if canceled {
+		return
+	}
+	b.failures++
+	if b.failures >= g.SignerBreakerThreshold {
+		b.openUntil = g.now().Add(g.SignerBreakerCooldown)
+	}
+}
+
+func (g *Generator) breakerTripped(b *breaker) bool {
+	if g.SignerBreakerThreshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= g.SignerBreakerThreshold
+}
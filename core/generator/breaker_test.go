@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestBreaker(t *testing.T) {
+	g := New(nil, nil, nil)
+	g.SignerBreakerThreshold = 2
+	g.SignerBreakerCooldown = time.Hour
+	b := new(breaker)
+	errDown := errors.New("down")
+
+	for i := 0; i < 2; i++ {
+		if !g.breakerAllow(b) {
+			t.Fatalf("breakerAllow = false after %d failures", i)
+		}
+		g.breakerRecord(b, errDown, false)
+	}
+	if !g.breakerTripped(b) || g.breakerAllow(b) {
+		t.Fatal("breaker not tripped after 2 failures")
+	}
+
+	// Canceled requests don't count as failures.
+	c := new(breaker)
+	g.breakerRecord(c, errDown, true)
+	g.breakerRecord(c, errDown, true)
+	if g.breakerTripped(c) {
+		t.Error("breaker tripped by canceled requests")
+	}
+
+	// After the cooldown, one probe is allowed at a time.
+	b.openUntil = time.Now()
+	if !g.breakerAllow(b) {
+		t.Fatal("breakerAllow = false after cooldown")
+	}
+	if g.breakerAllow(b) {
+		t.Fatal("breakerAllow = true while a probe is in flight")
+	}
+	g.breakerRecord(b, nil, false)
+	if g.breakerTripped(b) || !g.breakerAllow(b) {
+		t.Error("breaker still tripped after a successful probe")
+	}
+}
+
+func TestGetAndAddBlockSignaturesTripped(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	var calls int32
+	down := func() error {
+		atomic.AddInt32(&calls, 1)
+		return errors.New("signer unavailable")
+	}
+
+	g := New(c, []BlockSigner{testSigner{down, pubkeys[0], privkeys[0]}}, nil)
+	g.SignerBreakerThreshold = 1
+	g.SignerBreakerCooldown = time.Hour
+
+	ctx := context.Background()
+	tip, snapshot := c.State()
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	for i := 0; i < 2; i++ {
+		err = g.getAndAddBlockSignatures(ctx, block, tip)
+		if err == nil {
+			t.Fatal("signed block with no working signers")
+		}
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("signer called %d times, want 1", n)
+	}
+	signErr, ok := errors.Root(err).(*SignError)
+	if !ok || len(signErr.Failed) != 1 || signErr.Failed[0].Err != ErrSignerTripped {
+		t.Errorf("got error %v, want a SignError with a tripped signer", err)
+	}
+
+	hs, err := g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(hs.TrippedSigners) != 1 {
+		t.Errorf("Health reports %d tripped signers, want 1", len(hs.TrippedSigners))
+	}
+}
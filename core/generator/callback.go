@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"context"
+
+	"chain/log"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// A committedBlock is a block queued for OnBlockCommit.
+type committedBlock struct {
+	ctx context.Context
+	b   *legacy.Block
+	s   *state.Snapshot
+}
+
+// blockCommitted calls g.OnBlockCommit, if set, for b, either
+// directly or, if g.CommitCallbackBuffer is set, by queueing b for
+// runCommitCallbacks.
+func (g *Generator) blockCommitted(ctx context.Context, b *legacy.Block, s *state.Snapshot) {
+	if g.OnBlockCommit == nil {
+		return
+	}
+	if g.CommitCallbackBuffer <= 0 {
+		g.callOnBlockCommit(ctx, b, s)
+		return
+	}
+
+	g.commitQueueOnce.Do(func() {
+		g.commitQueue = make(chan committedBlock, g.CommitCallbackBuffer)
+		go g.runCommitCallbacks()
+	})
+	cb := committedBlock{ctx, b, s}
+	if !g.CommitCallbackDrop {
+		g.commitQueue <- cb
+		return
+	}
+	select {
+	case g.commitQueue <- cb:
+	default:
+		log.Printkv(ctx, log.KeyMessage, "commit callback queue is full; skipping block commit hook")
+		g.recordDroppedCallback()
+	}
+}
+
+// runCommitCallbacks calls g.OnBlockCommit for each block in
+// g.commitQueue. It runs for the life of the process.
+func (g *Generator) runCommitCallbacks() {
+	for cb := range g.commitQueue {
+		g.callOnBlockCommit(cb.ctx, cb.b, cb.s)
+	}
+}
+
+func (g *Generator) callOnBlockCommit(ctx context.Context, b *legacy.Block, s *state.Snapshot) {
+	err := g.OnBlockCommit(ctx, b, s)
+	if err != nil {
+		log.Printkv(ctx, log.KeyMessage, "block commit hook failed", log.KeyError, err)
+	}
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/protocol/state"
+	"chain/testutil"
+)
+
+func TestAsyncOnBlockCommit(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	heights := make(chan uint64, 10)
+
+	g := New(c, nil, nil)
+	g.CommitCallbackBuffer = 1
+	g.CommitCallbackDrop = true
+	g.OnBlockCommit = func(ctx context.Context, b *legacy.Block, s *state.Snapshot) error {
+		started <- struct{}{}
+		<-release
+		heights <- b.Height
+		return nil
+	}
+
+	commit := func() {
+		prev, snapshot := c.State()
+		b, s, err := c.GenerateBlock(ctx, prev, snapshot, prev.Time().Add(time.Millisecond), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = g.commitBlock(ctx, b, s, prev)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	commit() // the callback starts on block 2 and waits
+	<-started
+	commit() // block 3 is queued
+	commit() // the queue is full, so block 4 is dropped
+	if h := c.Height(); h != 4 {
+		t.Errorf("chain height = %d, want 4 while the callback is blocked", h)
+	}
+
+	close(release)
+	var got []uint64
+	for len(got) < 2 {
+		got = append(got, <-heights)
+	}
+	select {
+	case h := <-heights:
+		got = append(got, h)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if want := []uint64{2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("callback got heights %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// catchUp commits the blocks g.PeerFetch reports after the latest
+// block, until it reports no more, so that Generate doesn't build
+// on a stale tip. It does nothing if PeerFetch isn't set.
+func (g *Generator) catchUp(ctx context.Context) error {
+	if g.PeerFetch == nil {
+		return nil
+	}
+	start := g.nextHeight()
+	for {
+		latest, snapshot := g.latest()
+		if latest == nil {
+			return ErrNotBootstrapped
+		}
+		blocks, err := g.PeerFetch(ctx, latest.Height)
+		if err != nil {
+			return errors.Wrapf(err, "fetching blocks after height %d", latest.Height)
+		}
+		if len(blocks) == 0 {
+			break
+		}
+		for _, b := range blocks {
+			snapshot, err = g.commitPeerBlock(ctx, b, latest, snapshot)
+			if err != nil {
+				return errors.Wrapf(err, "committing fetched block %d", b.Height)
+			}
+			latest = b
+		}
+		log.Printkv(ctx, log.KeyMessage, "catching up before generating blocks", "height", latest.Height)
+	}
+	if n := g.nextHeight() - start; n > 0 {
+		log.Printkv(ctx, log.KeyMessage, "caught up before generating blocks", "blocks", n, "height", g.nextHeight()-1)
+	}
+	return nil
+}
+
+// commitPeerBlock validates and commits b, a block made elsewhere
+// that follows prev, and returns the resulting state.
+func (g *Generator) commitPeerBlock(ctx context.Context, b, prev *legacy.Block, snapshot *state.Snapshot) (*state.Snapshot, error) {
+	s, err := g.applyNextBlock(b, prev, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = g.chain.CommitAppliedBlock(ctx, b, s)
+	if err != nil {
+		return nil, err
+	}
+	g.advanceTip(b, s)
+	g.pruneCommitted(b)
+	return s, nil
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/protocol/prottest/memstore"
+	"chain/protocol/state"
+	"chain/testutil"
+)
+
+func TestCatchUp(t *testing.T) {
+	ctx := context.Background()
+	peer := prottest.NewChain(t)
+	initial := prottest.Initial(t, peer)
+	for i := 0; i < 3; i++ {
+		prev, snapshot := peer.State()
+		b, s, err := peer.GenerateBlock(ctx, prev, snapshot, prev.Time().Add(time.Millisecond), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = peer.CommitAppliedBlock(ctx, b, s)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	// c has only the initial block.
+	c, err := protocol.NewChain(ctx, initial.Hash(), memstore.New(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.CommitAppliedBlock(ctx, initial, state.Empty())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	g := New(c, nil, nil)
+	var calls int
+	g.PeerFetch = func(ctx context.Context, afterHeight uint64) ([]*legacy.Block, error) {
+		calls++
+		if afterHeight >= peer.Height() {
+			return nil, nil
+		}
+		b, err := peer.GetBlock(ctx, afterHeight+1) // one at a time
+		return []*legacy.Block{b}, err
+	}
+	err = g.catchUp(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if h := c.Height(); h != peer.Height() {
+		t.Errorf("height after catching up = %d, want %d", h, peer.Height())
+	}
+	if calls != 4 {
+		t.Errorf("PeerFetch called %d times, want 4", calls)
+	}
+}
@@ -0,0 +1,50 @@
+package generator
+
+import (
+	"chain/errors"
+	"chain/protocol"
+)
+
+// Categories of tx submission errors, as reported by
+// SubmitErrorCategory.
+var (
+	// ErrInvalidTx is the category of errors saying a tx can never
+	// be accepted, so resubmitting it won't help.
+	ErrInvalidTx = errors.New("invalid transaction")
+
+	// ErrTemporary is the category of errors saying a tx wasn't
+	// accepted this time but may be if it's submitted again later.
+	ErrTemporary = errors.New("temporary transaction submission failure")
+)
+
+// SubmitErrorCategory classifies err, an error from Submit,
+// SubmitTx or SubmitBatch, as ErrInvalidTx or ErrTemporary, so a
+// client can decide whether to submit the tx again. It returns nil
+// if err is nil. The errors keep their own roots, so they still
+// map to distinct API error codes; this only groups them.
+//
+// These roots are ErrInvalidTx:
+//   - ErrTxTooLarge, ErrTxExpired and ErrUnsupportedFormat
+//   - protocol.ErrBadTx, which Chain.ValidateTx returns for a tx
+//     that fails validation, typically called from TxValidator
+//   - ErrInvalidTx itself, which a TxValidator may return (for
+//     example with errors.Sub) for txs it refuses by policy
+//
+// These roots are ErrTemporary:
+//   - ErrRateLimited and ErrMempoolFull
+//   - context.DeadlineExceeded and context.Canceled
+//   - ErrTemporary itself
+//
+// Any other error, such as a failure to reach a TxSource, is
+// ErrTemporary too: submitting a tx again is always safe, and
+// nothing says this tx is bad.
+func SubmitErrorCategory(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch errors.Root(err) {
+	case ErrTxTooLarge, ErrTxExpired, protocol.ErrBadTx, ErrInvalidTx, ErrUnsupportedFormat:
+		return ErrInvalidTx
+	}
+	return ErrTemporary
+}
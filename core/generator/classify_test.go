@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/prottest"
+)
+
+func TestSubmitErrorCategory(t *testing.T) {
+	cases := []struct {
+		err  error
+		want error
+	}{
+		{nil, nil},
+		{errors.WithDetail(ErrTxTooLarge, "100 bytes"), ErrInvalidTx},
+		{errors.Sub(protocol.ErrBadTx, errors.New("bad signature")), ErrInvalidTx},
+		{errors.Sub(ErrInvalidTx, errors.New("policy")), ErrInvalidTx},
+		{ErrRateLimited, ErrTemporary},
+		{errors.WithDetail(ErrMempoolFull, "10 transactions are pending"), ErrTemporary},
+		{errors.Wrap(context.DeadlineExceeded), ErrTemporary},
+		{errors.New("tx source unreachable"), ErrTemporary},
+	}
+	for _, c := range cases {
+		if got := SubmitErrorCategory(c.err); got != c.want {
+			t.Errorf("SubmitErrorCategory(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestSubmitInvalidTxCategory(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	g.MaxTxBytes = 1
+	err := g.Submit(ctx, testTx(1, nil, nil))
+	if got := SubmitErrorCategory(err); got != ErrInvalidTx {
+		t.Errorf("category of Submit(oversized tx) error %v = %v, want %v", err, got, ErrInvalidTx)
+	}
+}
@@ -0,0 +1,44 @@
+package generator
+
+import "time"
+
+// A Clock is a source of time for a generator.
+// Tests can supply one that advances under their control.
+type Clock interface {
+	Now() time.Time
+
+	// Ticker returns a new Ticker that ticks every d.
+	Ticker(d time.Duration) Ticker
+}
+
+// A Ticker delivers ticks at intervals, like a time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the Clock used when Generator.Clock is unset.
+type realClock struct{}
+
+func (realClock) Now() time.Time                { return time.Now() }
+func (realClock) Ticker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+type realTicker struct{ t *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.t.C }
+func (t realTicker) Stop()               { t.t.Stop() }
+
+func (g *Generator) clock() Clock {
+	if g.Clock == nil {
+		return realClock{}
+	}
+	return g.Clock
+}
+
+func (g *Generator) now() time.Time {
+	return g.clock().Now()
+}
+
+func (g *Generator) since(t time.Time) time.Duration {
+	return g.now().Sub(t)
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// fakeClock is a Clock whose time changes only when advanced.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+type fakeTicker struct {
+	c    chan time.Time
+	d    time.Duration
+	next time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Ticker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{c: make(chan time.Time, 1), d: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// advance moves c forward by d, firing any tickers that come due.
+func (c *fakeClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.d)
+		}
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+func (t *fakeTicker) Stop()               {}
+
+func TestClock(t *testing.T) {
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c)
+	clock := &fakeClock{now: initial.Time().Add(time.Minute)}
+	g := New(c, nil, nil)
+	g.Clock = clock
+	g.MaxEmptyBlockInterval = time.Hour
+
+	b, _, err := g.AssembleBlock(context.Background())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if want := bc.Millis(clock.Now()); b.TimestampMS != want {
+		t.Errorf("block timestamp = %d, want %d", b.TimestampMS, want)
+	}
+
+	if due, _ := g.blockDue(initial, 0); due {
+		t.Error("empty block due before MaxEmptyBlockInterval")
+	}
+	clock.advance(time.Hour)
+	if due, _ := g.blockDue(initial, 0); !due {
+		t.Error("empty block not due after MaxEmptyBlockInterval")
+	}
+
+	ticker := g.clock().Ticker(time.Second)
+	clock.advance(time.Second)
+	select {
+	case <-ticker.C():
+	default:
+		t.Error("ticker didn't tick when the clock advanced")
+	}
+}
+
+func TestBlockTime(t *testing.T) {
+	ctx := context.Background()
+	prev := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 1, TimestampMS: 10000}}
+	clock := &fakeClock{now: prev.Time().Add(time.Second)}
+	g := New(nil, nil, nil)
+	g.Clock = clock
+	g.MaxFutureDrift = 5 * time.Second
+
+	got, err := g.blockTime(ctx, prev)
+	if err != nil || !got.Equal(clock.Now()) {
+		t.Errorf("blockTime with clock ahead = %s, %v, want %s, <nil>", got, err, clock.Now())
+	}
+
+	// A clock behind the previous block gives a timestamp just
+	// after it, as long as that's within MaxFutureDrift.
+	clock.now = prev.Time().Add(-2 * time.Second)
+	got, err = g.blockTime(ctx, prev)
+	if err != nil || bc.Millis(got) != prev.TimestampMS+1 {
+		t.Errorf("blockTime with clock behind = %d, %v, want %d, <nil>", bc.Millis(got), err, prev.TimestampMS+1)
+	}
+
+	clock.now = prev.Time().Add(-10 * time.Second)
+	_, err = g.blockTime(ctx, prev)
+	if errors.Root(err) != ErrClockDrift {
+		t.Errorf("blockTime with clock far behind = %v, want %v", err, ErrClockDrift)
+	}
+}
+
+func TestBlockTimestampFunc(t *testing.T) {
+	ctx := context.Background()
+	prev := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 1, TimestampMS: 10000}}
+	clock := &fakeClock{now: prev.Time().Add(1500 * time.Millisecond)}
+	g := New(nil, nil, nil)
+	g.Clock = clock
+	g.MaxFutureDrift = 5 * time.Second
+	g.TimestampFunc = func(*legacy.Block) time.Time { return clock.Now().Truncate(time.Second) }
+
+	got, err := g.blockTime(ctx, prev)
+	if want := prev.Time().Add(time.Second); err != nil || !got.Equal(want) {
+		t.Errorf("blockTime rounded to the second = %s, %v, want %s, <nil>", got, err, want)
+	}
+
+	// A result that isn't after prev is replaced.
+	g.TimestampFunc = func(prev *legacy.Block) time.Time { return prev.Time() }
+	got, err = g.blockTime(ctx, prev)
+	if err != nil || bc.Millis(got) != prev.TimestampMS+1 {
+		t.Errorf("blockTime with timestamp of prev = %d, %v, want %d, <nil>", bc.Millis(got), err, prev.TimestampMS+1)
+	}
+
+	g.TimestampFunc = func(*legacy.Block) time.Time { return clock.Now().Add(time.Minute) }
+	_, err = g.blockTime(ctx, prev)
+	if errors.Root(err) != ErrClockDrift {
+		t.Errorf("blockTime with timestamp far ahead = %v, want %v", err, ErrClockDrift)
+	}
+}
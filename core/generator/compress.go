@@ -0,0 +1,116 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"io"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+)
+
+// maxFramedBlockBytes bounds the length ReadCompressedBlocks
+// accepts for one block, so a corrupt stream can't make it
+// allocate without limit.
+const maxFramedBlockBytes = 64 << 20
+
+var errFramedBlockTooLarge = errors.New("framed block too large")
+
+// StreamBlocksCompressed writes each block with height greater than
+// afterHeight to w, in height order, as a gzip stream. Within the
+// stream, each block's binary encoding is preceded by its length as
+// a uvarint, so the reader can decode blocks as they arrive; see
+// ReadCompressedBlocks. The gzip level is BlockCompressionLevel.
+// Like StreamBlocks, it does not wait for new blocks.
+func (g *Generator) StreamBlocksCompressed(ctx context.Context, afterHeight uint64, w io.Writer) error {
+	enc, err := newBlockEncoder(w, g.BlockCompressionLevel)
+	if err != nil {
+		return err
+	}
+	err = g.StreamBlocks(ctx, afterHeight, enc.write)
+	if err != nil {
+		return err
+	}
+	return enc.close()
+}
+
+// ReadCompressedBlocks calls fn on each block in r, a stream
+// written by StreamBlocksCompressed, in order.
+func ReadCompressedBlocks(r io.Reader, fn func(*legacy.Block) error) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return errors.Wrap(err, "reading gzip header")
+	}
+	br := bufio.NewReader(zr)
+	var buf []byte
+	for {
+		n, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "reading block length")
+		}
+		if n > maxFramedBlockBytes {
+			return errors.WithDetailf(errFramedBlockTooLarge, "%d bytes", n)
+		}
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		buf = buf[:n]
+		_, err = io.ReadFull(br, buf)
+		if err != nil {
+			return errors.Wrap(err, "reading block")
+		}
+		b := new(legacy.Block)
+		err = b.Scan(buf)
+		if err != nil {
+			return errors.Wrap(err, "decoding block")
+		}
+		err = fn(b)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// A blockEncoder writes length-framed blocks to a gzip stream.
+type blockEncoder struct {
+	zw  *gzip.Writer
+	buf bytes.Buffer
+	n   [binary.MaxVarintLen64]byte
+}
+
+// newBlockEncoder returns a blockEncoder writing to w at the given
+// gzip level, or gzip.DefaultCompression if level is zero.
+func newBlockEncoder(w io.Writer, level int) (*blockEncoder, error) {
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	zw, err := gzip.NewWriterLevel(w, level)
+	if err != nil {
+		return nil, errors.Wrap(err, "compression level")
+	}
+	return &blockEncoder{zw: zw}, nil
+}
+
+func (e *blockEncoder) write(b *legacy.Block) error {
+	e.buf.Reset()
+	_, err := b.WriteTo(&e.buf)
+	if err != nil {
+		return errors.Wrap(err, "serializing block")
+	}
+	k := binary.PutUvarint(e.n[:], uint64(e.buf.Len()))
+	_, err = e.zw.Write(e.n[:k])
+	if err == nil {
+		_, err = e.zw.Write(e.buf.Bytes())
+	}
+	return errors.Wrap(err, "writing block")
+}
+
+func (e *blockEncoder) close() error {
+	return errors.Wrap(e.zw.Close(), "finishing gzip stream")
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func testBlocks(tb testing.TB, n int) []*legacy.Block {
+	c := prottest.NewChain(tb)
+	initial := prottest.Initial(tb, c)
+	blocks := []*legacy.Block{initial}
+	for i := 1; i < n; i++ {
+		tx := bctest.NewIssuanceTx(tb, initial.Hash())
+		blocks = append(blocks, prottest.MakeBlock(tb, c, []*legacy.Tx{tx}))
+	}
+	return blocks
+}
+
+func TestCompressedBlocks(t *testing.T) {
+	blocks := testBlocks(t, 3)
+
+	var buf bytes.Buffer
+	enc, err := newBlockEncoder(&buf, gzip.BestSpeed)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	for _, b := range blocks {
+		err = enc.write(b)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+	err = enc.close()
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	var got []*legacy.Block
+	err = ReadCompressedBlocks(&buf, func(b *legacy.Block) error {
+		got = append(got, b)
+		return nil
+	})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got) != len(blocks) {
+		t.Fatalf("read %d blocks, want %d", len(got), len(blocks))
+	}
+	for i, b := range got {
+		if b.Hash() != blocks[i].Hash() {
+			t.Errorf("block %d has hash %x, want %x", i, b.Hash().Bytes(), blocks[i].Hash().Bytes())
+		}
+	}
+}
+
+func BenchmarkEncodeBlocks(b *testing.B) {
+	blocks := testBlocks(b, 50)
+	b.Run("uncompressed", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var n int64
+			for _, blk := range blocks {
+				k, err := blk.WriteTo(ioutil.Discard)
+				if err != nil {
+					b.Fatal(err)
+				}
+				n += k
+			}
+			b.SetBytes(n)
+		}
+	})
+	for _, level := range []int{gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		level := level
+		b.Run(fmt.Sprintf("gzip%d", level), func(b *testing.B) {
+			var out bytes.Buffer
+			for i := 0; i < b.N; i++ {
+				out.Reset()
+				enc, err := newBlockEncoder(&out, level)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, blk := range blocks {
+					err = enc.write(blk)
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+				err = enc.close()
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+)
+
+// dbPingTimeout is how long PingDB waits for the database.
+const dbPingTimeout = 2 * time.Second
+
+// ErrNoDB is returned by the methods that need the generator's
+// database, such as the block readers and PingDB, when the
+// generator was made without one.
+var ErrNoDB = errors.New("generator has no database")
+
+// checkDB returns ErrNoDB if g has no database.
+func (g *Generator) checkDB() error {
+	if g.db == nil {
+		return ErrNoDB
+	}
+	return nil
+}
+
+// PingDB checks that the generator's database answers a trivial
+// query within a couple of seconds, so a health check can detect
+// a dead database before committing a block fails.
+func (g *Generator) PingDB(ctx context.Context) error {
+	_, err := g.pingDB(ctx)
+	return err
+}
+
+// pingDB is like PingDB, but also returns how long the query took.
+func (g *Generator) pingDB(ctx context.Context) (time.Duration, error) {
+	if err := g.checkDB(); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, dbPingTimeout)
+	defer cancel()
+
+	t0 := time.Now()
+	_, err := g.db.ExecContext(ctx, "SELECT 1")
+	return time.Since(t0), errors.Wrap(err, "pinging database")
+}
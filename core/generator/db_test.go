@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestNoDB(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+
+	cases := []struct {
+		name string
+		call func() error
+	}{
+		{"GetBlocks", func() error { _, err := g.GetBlocks(ctx, 0, 0); return err }},
+		{"GetBlocksSince", func() error { _, err := g.GetBlocksSince(ctx, 0, time.Second); return err }},
+		{"GetLatestBlocks", func() error { _, err := g.GetLatestBlocks(ctx, 0); return err }},
+		{"GetBlockRange", func() error { _, err := g.GetBlockRange(ctx, 1, 1); return err }},
+		{"GetBlockByHash", func() error { _, err := g.GetBlockByHash(ctx, bc.Hash{}); return err }},
+		{"StreamBlocks", func() error {
+			return g.StreamBlocks(ctx, 0, func(*legacy.Block) error { return nil })
+		}},
+		{"GetBlockSummaries", func() error { _, err := g.GetBlockSummaries(ctx, 0, 0); return err }},
+		{"RestoreSnapshot", func() error { return g.RestoreSnapshot(ctx, 1) }},
+		{"PingDB", func() error { return g.PingDB(ctx) }},
+	}
+	for _, c := range cases {
+		if err := c.call(); errors.Root(err) != ErrNoDB {
+			t.Errorf("%s() = %v, want %v", c.name, err, ErrNoDB)
+		}
+	}
+
+	// Committing a block needs the database to claim LeaderEpoch.
+	g.LeaderEpoch = 1
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	prev, snapshot := c.State()
+	b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.commitBlock(ctx, b, s, prev)
+	if errors.Root(err) != ErrNoDB {
+		t.Errorf("commitBlock() with LeaderEpoch = %v, want %v", err, ErrNoDB)
+	}
+	if h := c.Height(); h != 1 {
+		t.Errorf("chain height = %d, want 1", h)
+	}
+}
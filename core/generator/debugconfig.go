@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"fmt"
+	"net/url"
+
+	"chain/protocol/vm/vmutil"
+)
+
+// DebugConfig returns the generator's effective configuration, for
+// operators to inspect, in a form that can be encoded as JSON:
+// its options, with durations as strings and funcs and interfaces
+// reported only as whether they're set; its block period; the
+// quorum and number of keys in the latest block's consensus
+// program; and its block signers. AuditKey is left out, and signer
+// URLs have any password replaced, so the result is safe to show
+// to anyone allowed to see the generator's status. Core serves it
+// to internal clients at /rpc/generator/debug-config.
+func (g *Generator) DebugConfig() map[string]interface{} {
+	var signers []map[string]interface{}
+	for _, info := range g.Signers() {
+		s := map[string]interface{}{
+			"local":   info.Local,
+			"tripped": info.Tripped,
+		}
+		if info.URL != "" {
+			s["url"] = redactURL(info.URL)
+		}
+		if info.Pubkey != nil {
+			s["pubkey"] = fmt.Sprintf("%x", []byte(info.Pubkey))
+		}
+		signers = append(signers, s)
+	}
+
+	cfg := map[string]interface{}{
+		"period":   g.Period().String(),
+		"paused":   g.IsPaused(),
+		"observer": g.IsObserver(),
+		"signers":  signers,
+
+		"min_tx_per_block":           g.MinTxPerBlock,
+		"max_tx_per_block":           g.MaxTxPerBlock,
+		"max_tx_bytes":               g.MaxTxBytes,
+		"max_pending_txs":            g.MaxPendingTxs,
+		"max_tx_age":                 g.MaxTxAge.String(),
+		"enforce_tx_max_time":        g.EnforceTxMaxTime,
+		"max_empty_block_interval":   g.MaxEmptyBlockInterval.String(),
+		"submit_rate_limit":          g.SubmitRateLimit,
+		"submit_rate_burst":          g.SubmitRateBurst,
+		"recent_tx_cache_size":       g.RecentTxCacheSize,
+		"recent_tx_ttl":              g.RecentTxTTL.String(),
+		"idempotency_key_cache_size": g.IdempotencyKeyCacheSize,
+		"idempotency_key_ttl":        g.IdempotencyKeyTTL.String(),
+		"validation_workers":         g.ValidationWorkers,
+		"tx_ordering":                int(g.TxOrdering),
+		"drop_invalid_tx_on_commit":  g.DropInvalidTxOnCommit,
+		"block_version":              g.blockVersion(),
+		"max_future_drift":           g.MaxFutureDrift.String(),
+		"slow_block_threshold":       g.slowBlockThreshold().String(),
+		"leader_epoch":               g.LeaderEpoch,
+
+		"signing_timeout":          g.SigningTimeout.String(),
+		"signer_hedge_delay":       g.SignerHedgeDelay.String(),
+		"header_only_signing":      g.HeaderOnlySigning,
+		"signer_retries":           g.SignerRetries,
+		"signer_retry_backoff":     g.SignerRetryBackoff.String(),
+		"signer_breaker_threshold": g.SignerBreakerThreshold,
+		"signer_breaker_cooldown":  g.SignerBreakerCooldown.String(),
+		"commit_retries":           g.CommitRetries,
+		"commit_retry_backoff":     g.CommitRetryBackoff.String(),
+
+		"snapshot_interval":          g.SnapshotInterval,
+		"snapshot_prune_interval":    g.SnapshotPruneInterval,
+		"recovery_verify_depth":      g.RecoveryVerifyDepth,
+		"max_concurrent_block_reads": g.MaxConcurrentBlockReads,
+		"block_fetch_batch_size":     g.BlockFetchBatchSize,
+		"block_compression_level":    g.BlockCompressionLevel,
+		"commit_callback_buffer":     g.CommitCallbackBuffer,
+		"commit_callback_drop":       g.CommitCallbackDrop,
+		"tx_callback_workers":        g.TxCallbackWorkers,
+		"tx_callback_buffer":         g.TxCallbackBuffer,
+		"audit_fatal":                g.AuditFatal,
+		"enable_metrics":             g.EnableMetrics,
+		"enable_tracing":             g.EnableTracing,
+
+		"tx_validator":           g.TxValidator != nil,
+		"tx_source":              g.TxSource != nil,
+		"tx_priority":            g.TxPriority != nil,
+		"on_tx_expired":          g.OnTxExpired != nil,
+		"on_tx_accepted":         g.OnTxAccepted != nil,
+		"on_block_commit":        g.OnBlockCommit != nil,
+		"on_leadership_acquired": g.OnLeadershipAcquired != nil,
+		"on_leadership_lost":     g.OnLeadershipLost != nil,
+		"timestamp_func":         g.TimestampFunc != nil,
+		"backpressure":           g.Backpressure != nil,
+		"schedule":               g.Schedule != nil,
+		"peer_fetch":             g.PeerFetch != nil,
+		"audit_sink":             g.AuditSink != nil,
+		"audit_key":              g.AuditKey != nil,
+		"clock":                  g.Clock != nil,
+	}
+
+	if g.chain != nil {
+		if latest, _ := g.latest(); latest != nil {
+			pubkeys, quorum, err := vmutil.ParseBlockMultiSigProgram(latest.ConsensusProgram)
+			if err == nil {
+				cfg["quorum"] = quorum
+				cfg["consensus_keys"] = len(pubkeys)
+			}
+		}
+	}
+	return cfg
+}
+
+// redactURL returns s with the password in its user info, if any,
+// replaced. It returns a placeholder if s isn't a valid URL, since
+// it can't tell what part of s is secret.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "(invalid URL)"
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "redacted")
+		}
+	}
+	return u.String()
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrNotPending is returned by EstimateConfirmation when the tx
+// isn't pending.
+var ErrNotPending = errors.New("transaction is not pending")
+
+// EstimateConfirmation returns a rough estimate of how long from
+// now until the pending tx with the given ID is in a block. It
+// counts the blocks needed to reach the tx with the pending txs as
+// they are now, at MaxTxPerBlock per block and in the order given
+// by TxPriority, if set, so a higher-priority tx submitted later
+// can push the estimate back. It assumes one block per period,
+// starting at the next tick, and ignores MinTxPerBlock and txs
+// that turn out to be invalid.
+//
+// It returns ErrNotPending if the tx isn't pending, and
+// ErrBadPeriod if Generate hasn't set a block period.
+func (g *Generator) EstimateConfirmation(ctx context.Context, id bc.Hash) (time.Duration, error) {
+	period := g.Period()
+	if period <= 0 {
+		return 0, ErrBadPeriod
+	}
+
+	var pending []*legacy.Tx
+	if g.TxSource != nil {
+		var err error
+		pending, err = g.TxSource.Pending(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "getting pending txs")
+		}
+	} else {
+		g.mu.Lock()
+		pending = append([]*legacy.Tx(nil), g.pool...)
+		g.mu.Unlock()
+	}
+
+	blocks := g.blocksUntil(pending, id)
+	if blocks == 0 {
+		return 0, ErrNotPending
+	}
+
+	// The next block comes one period after the latest, as near as
+	// the generator can tell without knowing the ticker's phase.
+	next := period
+	if latest, _ := g.latest(); latest != nil {
+		next = period - g.since(latest.Time())
+		if next < 0 {
+			next = 0
+		} else if next > period {
+			next = period
+		}
+	}
+	return next + time.Duration(blocks-1)*period, nil
+}
+
+// blocksUntil returns the number of blocks made from pending, as
+// chosen by splitTxs, until the one containing the tx with the
+// given ID, counting that block, or 0 if the tx isn't in pending.
+func (g *Generator) blocksUntil(pending []*legacy.Tx, id bc.Hash) int {
+	for n := 1; len(pending) > 0; n++ {
+		take, keep := g.splitTxs(pending)
+		if len(take) == 0 {
+			break
+		}
+		for _, tx := range take {
+			if tx.ID == id {
+				return n
+			}
+		}
+		pending = keep
+	}
+	return 0
+}
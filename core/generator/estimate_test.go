@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestEstimateConfirmation(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c)
+	const period = 10 * time.Second
+
+	g := New(c, nil, nil)
+	g.Clock = &fakeClock{now: initial.Time().Add(period / 2)}
+	g.MaxTxPerBlock = 2
+	_, err := g.EstimateConfirmation(ctx, bc.Hash{})
+	if err != ErrBadPeriod {
+		t.Errorf("EstimateConfirmation() before Generate = %v, want %v", err, ErrBadPeriod)
+	}
+	g.period = period
+
+	for i := byte(1); i <= 5; i++ {
+		g.pool = append(g.pool, testTx(i, nil, nil))
+	}
+	last := g.pool[4].ID
+
+	got, err := g.EstimateConfirmation(ctx, last)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if want := period/2 + 2*period; got != want {
+		t.Errorf("EstimateConfirmation(5th of 5 txs) = %s, want %s", got, want)
+	}
+
+	g.TxPriority = func(tx *legacy.Tx) int { return int(tx.ID.Bytes()[0]) }
+	got, err = g.EstimateConfirmation(ctx, last)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if want := period / 2; got != want {
+		t.Errorf("EstimateConfirmation(highest priority tx) = %s, want %s", got, want)
+	}
+
+	_, err = g.EstimateConfirmation(ctx, testTx(6, nil, nil).ID)
+	if err != ErrNotPending {
+		t.Errorf("EstimateConfirmation(unknown tx) = %v, want %v", err, ErrNotPending)
+	}
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// checkTxMaxTime returns ErrTxExpired if g.EnforceTxMaxTime is set
+// and tx's max time has passed.
+func (g *Generator) checkTxMaxTime(tx *legacy.Tx) error {
+	if !g.EnforceTxMaxTime {
+		return nil
+	}
+	now := bc.Millis(g.now())
+	if pastMaxTime(tx, now) {
+		return errors.WithDetailf(ErrTxExpired, "max time %d, now %d", tx.MaxTimeMs, now)
+	}
+	return nil
+}
+
+// pastMaxTime reports whether tx has a max time before nowMS,
+// so no block made now or later can include it.
+func pastMaxTime(tx *legacy.Tx, nowMS uint64) bool {
+	return tx.MaxTimeMs != 0 && tx.MaxTimeMs < nowMS
+}
+
+// expireTxs removes txs pending longer than MaxTxAge, and, with
+// EnforceTxMaxTime, txs whose max time has passed, from the pending
+// tx pool, logs each one, and reports them to OnTxExpired.
+func (g *Generator) expireTxs(ctx context.Context) {
+	if (g.MaxTxAge <= 0 && !g.EnforceTxMaxTime) || g.TxSource != nil {
+		return
+	}
+
+	var expired, pastMax []*legacy.Tx
+	now := bc.Millis(g.now())
+	g.mu.Lock()
+	keep := g.pool[:0]
+	for _, tx := range g.pool {
+		if g.EnforceTxMaxTime && pastMaxTime(tx, now) {
+			pastMax = append(pastMax, tx)
+			delete(g.poolTimes, tx.ID)
+			continue
+		}
+		if g.MaxTxAge > 0 && g.since(g.poolTimes[tx.ID]) > g.MaxTxAge {
+			expired = append(expired, tx)
+			delete(g.poolTimes, tx.ID)
+			continue
+		}
+		keep = append(keep, tx)
+	}
+	for i := len(keep); i < len(g.pool); i++ {
+		g.pool[i] = nil // let the expired txs be collected
+	}
+	g.pool = keep
+	g.recordPending()
+	g.mu.Unlock()
+	if len(expired)+len(pastMax) == 0 {
+		return
+	}
+
+	// Let clients resubmit them, if only to learn they've expired.
+	g.forgetSubmitted(expired)
+	g.forgetSubmitted(pastMax)
+	g.recordExpired(len(expired) + len(pastMax))
+	for _, tx := range expired {
+		log.Printkv(ctx, log.KeyMessage, "dropped pending transaction older than max tx age",
+			"tx", fmt.Sprintf("%x", tx.ID.Bytes()), "max_tx_age", g.MaxTxAge)
+		if g.OnTxExpired != nil {
+			g.OnTxExpired(ctx, tx)
+		}
+	}
+	for _, tx := range pastMax {
+		log.Printkv(ctx, log.KeyMessage, "dropped pending transaction past its max time",
+			"tx", fmt.Sprintf("%x", tx.ID.Bytes()), "max_time_ms", tx.MaxTimeMs)
+		if g.OnTxExpired != nil {
+			g.OnTxExpired(ctx, tx)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+)
+
+func TestExpireTxs(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	g := New(prottest.NewChain(t), nil, nil)
+	g.Clock = clock
+	g.MaxTxAge = time.Minute
+	g.RecentTxCacheSize = 10
+	var expired []*legacy.Tx
+	g.OnTxExpired = func(_ context.Context, tx *legacy.Tx) {
+		expired = append(expired, tx)
+	}
+
+	old, young := testTx(1, nil, nil), testTx(2, nil, nil)
+	g.mu.Lock()
+	g.addTx(old)
+	clock.advance(time.Minute)
+	g.addTx(young)
+	g.mu.Unlock()
+	clock.advance(time.Second)
+
+	g.expireTxs(ctx)
+	if !reflect.DeepEqual(expired, []*legacy.Tx{old}) {
+		t.Errorf("expired txs = %v, want tx 1", txIDs(expired))
+	}
+	if got := txIDs(g.PendingTxs()); !reflect.DeepEqual(got, []byte{2}) {
+		t.Errorf("pending txs = %v, want [2]", got)
+	}
+	if g.recentlySubmitted(old.ID) {
+		t.Error("expired tx is still remembered as recently submitted")
+	}
+}
+
+func TestEnforceTxMaxTime(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Now()}
+	g := New(prottest.NewChain(t), nil, nil)
+	g.Clock = clock
+	g.EnforceTxMaxTime = true
+	var expired []*legacy.Tx
+	g.OnTxExpired = func(_ context.Context, tx *legacy.Tx) {
+		expired = append(expired, tx)
+	}
+	withMaxTime := func(id byte, maxTime time.Time) *legacy.Tx {
+		tx := testTx(id, nil, nil)
+		tx.MaxTimeMs = bc.Millis(maxTime)
+		return tx
+	}
+
+	err := g.Submit(ctx, withMaxTime(1, clock.Now().Add(-time.Second)))
+	if errors.Root(err) != ErrTxExpired {
+		t.Errorf("Submit(expired tx) error = %v, want %v", err, ErrTxExpired)
+	}
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{withMaxTime(1, clock.Now().Add(-time.Second))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errors.Root(errs[0]) != ErrTxExpired {
+		t.Errorf("SubmitBatch(expired tx) error = %v, want %v", errs[0], ErrTxExpired)
+	}
+
+	// Tx 2 expires while it's pending; tx 3 has no max time.
+	soon := withMaxTime(2, clock.Now().Add(time.Minute))
+	for _, tx := range []*legacy.Tx{soon, testTx(3, nil, nil)} {
+		err = g.Submit(ctx, tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.expireTxs(ctx)
+	if len(expired) != 0 {
+		t.Errorf("expired txs = %v before their max time, want none", txIDs(expired))
+	}
+
+	clock.advance(time.Minute + time.Second)
+	g.expireTxs(ctx)
+	if !reflect.DeepEqual(expired, []*legacy.Tx{soon}) {
+		t.Errorf("expired txs = %v, want tx 2", txIDs(expired))
+	}
+	if got := txIDs(g.PendingTxs()); !reflect.DeepEqual(got, []byte{3}) {
+		t.Errorf("pending txs = %v, want [3]", got)
+	}
+	err = g.Submit(ctx, soon)
+	if errors.Root(err) != ErrTxExpired {
+		t.Errorf("resubmitting an expired tx: error = %v, want %v", err, ErrTxExpired)
+	}
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrFenced is returned when the generator's LeaderEpoch is lower
+// than one another generator has already claimed, meaning a newer
+// leader has taken over. The generator stops making blocks.
+var ErrFenced = errors.New("fenced by a newer leader epoch")
+
+// saveFencedBlock saves b in the database in the same statement
+// that records g.LeaderEpoch, so b is stored only if no higher
+// epoch has been recorded, returning ErrFenced if one has. It
+// does nothing if LeaderEpoch is zero, and returns ErrNoDB if it
+// isn't and g has no database.
+func (g *Generator) saveFencedBlock(ctx context.Context, b *legacy.Block) error {
+	if g.LeaderEpoch == 0 {
+		return nil
+	}
+	if err := g.checkDB(); err != nil {
+		return err
+	}
+	ok, err := g.store.SaveBlockAtEpoch(ctx, b, g.LeaderEpoch)
+	if err != nil {
+		return errors.Wrap(err, "saving block")
+	}
+	if !ok {
+		return errors.WithDetailf(ErrFenced, "leader epoch %d", g.LeaderEpoch)
+	}
+	return nil
+}
+
+// claimEpoch records g.LeaderEpoch as the current leadership epoch,
+// returning ErrFenced if a higher epoch has been recorded.
+// It does nothing if LeaderEpoch is zero, and returns ErrNoDB
+// if it isn't and g has no database to record it in.
+func (g *Generator) claimEpoch(ctx context.Context) error {
+	if g.LeaderEpoch == 0 {
+		return nil
+	}
+	if err := g.checkDB(); err != nil {
+		return err
+	}
+	const q = `
+		INSERT INTO generator_epoch (epoch) VALUES ($1)
+		ON CONFLICT (singleton) DO UPDATE SET epoch = excluded.epoch
+			WHERE generator_epoch.epoch <= excluded.epoch
+	`
+	res, err := g.db.ExecContext(ctx, q, g.LeaderEpoch)
+	if err != nil {
+		return errors.Wrap(err, "generator_epoch insert query")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "generator_epoch rows affected")
+	}
+	if n == 0 {
+		return errors.WithDetailf(ErrFenced, "leader epoch %d", g.LeaderEpoch)
+	}
+	return nil
+}
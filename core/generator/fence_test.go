@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/core/txdb"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestClaimEpoch(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+
+	newLeader := New(nil, nil, db)
+	newLeader.LeaderEpoch = 2
+	oldLeader := New(nil, nil, db)
+	oldLeader.LeaderEpoch = 1
+
+	err := oldLeader.claimEpoch(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = newLeader.claimEpoch(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = newLeader.claimEpoch(ctx) // claiming the same epoch again is fine
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = oldLeader.claimEpoch(ctx)
+	if errors.Root(err) != ErrFenced {
+		t.Errorf("claimEpoch(1) after epoch 2 = %v, want %v", err, ErrFenced)
+	}
+}
+
+func TestCommitBlockFenced(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	store := txdb.NewStore(db)
+	c := prottest.NewChain(t, prottest.WithStore(store))
+
+	oldLeader := New(c, nil, db)
+	oldLeader.LeaderEpoch = 1
+	err := oldLeader.claimEpoch(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// A newer leader claims its epoch while the old leader
+	// is committing a block, after any check the old leader
+	// made before writing the block.
+	newLeader := New(c, nil, db)
+	newLeader.LeaderEpoch = 2
+	oldLeader.AuditSink = epochClaimer{ctx, newLeader}
+
+	commit := func(g *Generator) error {
+		g.makeMu.Lock()
+		defer g.makeMu.Unlock()
+		prev, snapshot := c.State()
+		b, s, err := c.GenerateBlock(ctx, prev, snapshot, time.Now(), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		return g.commitBlock(ctx, b, s, prev)
+	}
+	err = commit(oldLeader)
+	if errors.Root(err) != ErrFenced {
+		t.Errorf("old leader's commitBlock() = %v, want %v", err, ErrFenced)
+	}
+	if h, err := store.Height(ctx); err != nil || h != 1 {
+		t.Errorf("stored height after fenced commit = %d, %v, want 1", h, err)
+	}
+
+	err = commit(newLeader)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if h, err := store.Height(ctx); err != nil || h != 2 {
+		t.Errorf("stored height after new leader's commit = %d, %v, want 2", h, err)
+	}
+}
+
+// epochClaimer is an audit sink that claims a generator's leader
+// epoch when it's written to.
+type epochClaimer struct {
+	ctx context.Context
+	g   *Generator
+}
+
+func (e epochClaimer) Write(p []byte) (int, error) {
+	return len(p), e.g.claimEpoch(e.ctx)
+}
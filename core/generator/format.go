@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrUnsupportedFormat is returned by DecodeTx and SubmitEncoded
+// for a tx encoding format they don't recognize.
+var ErrUnsupportedFormat = errors.New("unsupported transaction format")
+
+// Tx encoding formats for DecodeTx and SubmitEncoded.
+const (
+	// TxFormatBinary is the canonical binary serialization of a
+	// tx, as written by TxData.WriteTo.
+	TxFormatBinary = "binary"
+
+	// TxFormatHex is the binary serialization, hex-encoded, as
+	// produced by TxData.MarshalText.
+	TxFormatHex = "hex"
+
+	// TxFormatJSON is a JSON string holding the hex form, which is
+	// how Chain Core's API and SDKs send transactions, for example
+	// as the raw_transaction of a signed transaction template.
+	TxFormatJSON = "json"
+)
+
+// DecodeTx decodes a tx from data in the given format, one of the
+// TxFormat constants. A tx that can't be decoded gives an error
+// with root ErrInvalidTx; an unknown format gives
+// ErrUnsupportedFormat.
+func DecodeTx(format string, data []byte) (*legacy.Tx, error) {
+	var text []byte
+	switch format {
+	case TxFormatBinary:
+		text = make([]byte, hex.EncodedLen(len(data)))
+		hex.Encode(text, data)
+	case TxFormatHex:
+		text = data
+	case TxFormatJSON:
+		var s string
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return nil, errors.Sub(ErrInvalidTx, err)
+		}
+		text = []byte(s)
+	default:
+		return nil, errors.WithDetailf(ErrUnsupportedFormat, "format %q", format)
+	}
+	tx := new(legacy.Tx)
+	err := tx.UnmarshalText(text)
+	if err != nil {
+		return nil, errors.Sub(ErrInvalidTx, err)
+	}
+	return tx, nil
+}
+
+// SubmitEncoded is like Submit, but takes the tx encoded in the
+// given format; see DecodeTx.
+func (g *Generator) SubmitEncoded(ctx context.Context, format string, data []byte) error {
+	tx, err := DecodeTx(format, data)
+	if err != nil {
+		return err
+	}
+	return g.Submit(ctx, tx)
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"chain/errors"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/prottest"
+)
+
+func TestDecodeTx(t *testing.T) {
+	c := prottest.NewChain(t)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+
+	var bin bytes.Buffer
+	_, err := tx.WriteTo(&bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := tx.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		format string
+		data   []byte
+	}{
+		{TxFormatBinary, bin.Bytes()},
+		{TxFormatHex, text},
+		{TxFormatJSON, js},
+	}
+	for _, c := range cases {
+		got, err := DecodeTx(c.format, c.data)
+		if err != nil {
+			t.Errorf("DecodeTx(%q) error = %v", c.format, err)
+			continue
+		}
+		if got.ID != tx.ID {
+			t.Errorf("DecodeTx(%q) = tx %x, want %x", c.format, got.ID.Bytes(), tx.ID.Bytes())
+		}
+	}
+}
+
+func TestDecodeTxErrors(t *testing.T) {
+	cases := []struct {
+		format string
+		data   []byte
+		want   error
+	}{
+		{"protobuf", []byte{1}, ErrUnsupportedFormat},
+		{TxFormatBinary, []byte{0xff, 0xff}, ErrInvalidTx},
+		{TxFormatHex, []byte("zz"), ErrInvalidTx},
+		{TxFormatJSON, []byte("{}"), ErrInvalidTx},
+	}
+	for _, c := range cases {
+		_, err := DecodeTx(c.format, c.data)
+		if errors.Root(err) != c.want {
+			t.Errorf("DecodeTx(%q, %x) error = %v, want root %v", c.format, c.data, err, c.want)
+		}
+		if got := SubmitErrorCategory(err); got != ErrInvalidTx {
+			t.Errorf("category of DecodeTx(%q, %x) error = %v, want %v", c.format, c.data, got, ErrInvalidTx)
+		}
+	}
+}
+
+func TestSubmitEncoded(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+
+	var bin bytes.Buffer
+	_, err := tx.WriteTo(&bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.SubmitEncoded(ctx, TxFormatBinary, bin.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := g.PendingTxs()
+	if len(pending) != 1 || pending[0].ID != tx.ID {
+		t.Errorf("pending txs after SubmitEncoded = %v, want [%x]", pending, tx.ID.Bytes())
+	}
+}
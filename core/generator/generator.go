@@ -6,14 +6,53 @@ package generator
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/golang/groupcache/lru"
+
+	"chain/core/txdb"
+	"chain/crypto/ed25519"
 	"chain/database/pg"
+	"chain/errors"
 	"chain/log"
+	"chain/net/http/authn"
+	"chain/net/http/limit"
 	"chain/protocol"
 	"chain/protocol/bc"
 	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+	"chain/protocol/vm/vmutil"
+)
+
+var (
+	// ErrBadPeriod is returned when attempting to use a block period
+	// that is not positive.
+	ErrBadPeriod = errors.New("block period must be positive")
+
+	// ErrDuplicateTx is reported by SubmitBatch for a transaction
+	// that is already in the pending tx pool.
+	ErrDuplicateTx = errors.New("transaction already pending")
+
+	// ErrTxTooLarge is returned when submitting a transaction
+	// whose serialized size exceeds MaxTxBytes.
+	ErrTxTooLarge = errors.New("transaction too large")
+
+	// ErrRateLimited is returned when submitting a transaction
+	// would exceed SubmitRateLimit.
+	ErrRateLimited = errors.New("transaction submission rate limit exceeded")
+
+	// ErrMempoolFull is returned when submitting a transaction
+	// while the pending tx pool holds MaxPendingTxs transactions.
+	ErrMempoolFull = errors.New("pending transaction pool is full")
+
+	// ErrTxExpired is returned when submitting a transaction whose
+	// max time has passed, if EnforceTxMaxTime is set.
+	ErrTxExpired = errors.New("transaction max time has passed")
 )
 
 // A BlockSigner signs blocks.
@@ -26,31 +65,583 @@ type BlockSigner interface {
 
 // Generator collects pending transactions and produces new blocks on
 // an interval.
+//
+// The exported fields configure optional behavior. They must be set
+// before the Generator is first used and not changed afterward.
 type Generator struct {
+	// MinTxPerBlock is the number of pending transactions needed
+	// before Generate will make a new block. If it's zero, any
+	// nonzero number of pending transactions is enough.
+	MinTxPerBlock int
+
+	// MaxTxBytes, if nonzero, is the largest serialized transaction
+	// Submit will accept into the pending tx pool.
+	MaxTxBytes int
+
+	// TxValidator, if set, is called on each submitted transaction
+	// to apply policy checks. A transaction it returns an error for
+	// is rejected with that error. It runs after the rate limit and
+	// recently-submitted checks, and before the pending tx pool's
+	// duplicate, capacity, and size checks. It may be called
+	// concurrently.
+	TxValidator func(ctx context.Context, tx *legacy.Tx) error
+
+	// TxSource, if set, holds pending transactions instead of
+	// the generator's built-in pending tx pool. Submit adds to it,
+	// and each block is made from its pending txs, which are
+	// removed once the block is committed. PendingTxs,
+	// PendingTxInfo, and MaxPendingTxs apply only to the built-in
+	// pool, and TxStatus reports such txs as Unknown until they're
+	// committed.
+	TxSource TxSource
+
+	// MaxPendingTxs, if nonzero, is the most transactions the
+	// pending tx pool will hold. Submit rejects new transactions
+	// with ErrMempoolFull while it's full.
+	MaxPendingTxs int
+
+	// SubmitRateLimit, if nonzero, is the number of transactions
+	// per second each client may submit, with bursts of up to
+	// SubmitRateBurst (or SubmitRateLimit, if that's zero).
+	// Clients are told apart by the access token in their request
+	// context; requests without one share a single limit.
+	SubmitRateLimit int
+	SubmitRateBurst int
+
+	// RecentTxCacheSize, if nonzero, is the number of recently
+	// accepted transactions Submit remembers, so that resubmitting
+	// one of them within RecentTxTTL (if nonzero) is reported as a
+	// duplicate right away. A transaction is forgotten once it's
+	// committed in a block.
+	RecentTxCacheSize int
+	RecentTxTTL       time.Duration
+
+	// IdempotencyKeyCacheSize is the number of idempotency keys
+	// SubmitWithKey remembers, or DefaultIdempotencyKeyCacheSize if
+	// it's zero. Each is kept for IdempotencyKeyTTL, if nonzero,
+	// or until it's one of the least recently used.
+	IdempotencyKeyCacheSize int
+	IdempotencyKeyTTL       time.Duration
+
+	// MaxTxPerBlock, if nonzero, is the most pending transactions
+	// that will go into one block. Transactions are taken oldest
+	// first; the rest stay pending for later blocks. This limits the
+	// number of transactions, not their total size: MaxTxBytes caps
+	// the size of each transaction as it's submitted, so with both
+	// set a block holds at most MaxTxPerBlock*MaxTxBytes bytes of
+	// transactions.
+	MaxTxPerBlock int
+
+	// TxPriority, if set, decides which transactions go into a
+	// block when there are more than MaxTxPerBlock pending. Higher
+	// priority transactions go first, with ties going to the one
+	// submitted first. A transaction that spends an output of
+	// another pending transaction waits until that one is chosen.
+	// Without TxPriority, transactions are taken oldest first.
+	TxPriority func(*legacy.Tx) int
+
+	// MaxTxAge, if nonzero, is the longest a transaction can stay
+	// in the pending tx pool. Each time the generator takes
+	// transactions for a block, it drops those left behind that
+	// have been pending longer, logs them, and calls OnTxExpired,
+	// if set, for each. It doesn't apply to a TxSource.
+	MaxTxAge    time.Duration
+	OnTxExpired func(context.Context, *legacy.Tx)
+
+	// EnforceTxMaxTime makes the generator refuse, with
+	// ErrTxExpired, a submitted transaction whose max time has
+	// passed, since no block could include it. Each time the
+	// generator takes transactions for a block, it also drops
+	// pending ones whose max time has since passed, as it does for
+	// MaxTxAge. Without it, such transactions wait in the pool
+	// until they fail validation for a block. It doesn't apply to
+	// a TxSource's pending txs, but does to submissions to it.
+	EnforceTxMaxTime bool
+
+	// ValidationWorkers is the number of goroutines that validate
+	// the transactions for a block before they're applied to the
+	// state, which happens one at a time in block order. If it's
+	// zero, it's runtime.GOMAXPROCS(0); 1 validates them serially.
+	ValidationWorkers int
+
+	// TxOrdering determines the order of transactions within
+	// each block. The default is OrderByArrival.
+	TxOrdering TxOrdering
+
+	// MaxEmptyBlockInterval, if nonzero, is the longest Generate will
+	// go without making a block. Once that long has passed since the
+	// latest block, Generate makes a new block regardless of
+	// MinTxPerBlock, even if the block is empty.
+	//
+	// Generate never makes an empty block otherwise, so this acts as
+	// a heartbeat that keeps block timestamps advancing on an idle
+	// chain. Neither setting affects committing a pending block left
+	// by an earlier attempt; that always happens first.
+	MaxEmptyBlockInterval time.Duration
+
+	// SignerRetries is the number of times to retry a failed request
+	// to a block signer before giving up on that signer for the
+	// current block. Retries never extend past the block period.
+	SignerRetries int
+
+	// SignerRetryBackoff is the wait before the first retry of a
+	// failed signing request. Each later retry waits about twice as
+	// long as the one before, with random jitter.
+	SignerRetryBackoff time.Duration
+
+	// CommitRetries is the number of times to retry committing a
+	// signed block to storage after an error, such as a brief
+	// database outage, before giving up until the next period.
+	// CommitRetryBackoff is the wait before the first retry; it
+	// doubles with each one, with random jitter. Retrying stops if
+	// Generate's context is canceled.
+	CommitRetries      int
+	CommitRetryBackoff time.Duration
+
+	// OnBlockCommit, if set, is called after each block the
+	// generator commits, with the block and the resulting state.
+	// It runs synchronously on the goroutine making blocks, so it
+	// should hand off any slow work, unless CommitCallbackBuffer is
+	// set. An error is logged; the block stays committed.
+	OnBlockCommit func(ctx context.Context, b *legacy.Block, s *state.Snapshot) error
+
+	// CommitCallbackBuffer, if nonzero, makes OnBlockCommit run on
+	// a separate goroutine, one block at a time in commit order,
+	// with up to this many committed blocks queued for it. When the
+	// queue is full, the generator waits for room before it makes
+	// the next block, unless CommitCallbackDrop is set, in which
+	// case it skips the callback for that block and counts it in
+	// the generator.dropped_commit_callbacks metric.
+	CommitCallbackBuffer int
+	CommitCallbackDrop   bool
+
+	// OnTxAccepted, if set, is called for each tx Submit, SubmitTx
+	// or SubmitBatch newly accepts into the pending txs, before it
+	// returns, so clients can learn of pending txs before they're
+	// in a block. It isn't called for duplicates or rejected txs.
+	// An error is logged; the tx stays accepted.
+	//
+	// TxCallbackWorkers, if nonzero, makes OnTxAccepted run on that
+	// many goroutines instead, with up to TxCallbackBuffer accepted
+	// txs queued for them, so a slow callback doesn't slow down
+	// submissions. When all workers are busy and the queue is full,
+	// the callback is skipped for the tx, and counted in the
+	// generator.dropped_tx_callbacks metric.
+	OnTxAccepted      func(ctx context.Context, tx *legacy.Tx) error
+	TxCallbackWorkers int
+	TxCallbackBuffer  int
+
+	// SnapshotInterval, if nonzero, makes the generator save the
+	// state snapshot after committing each block whose height is a
+	// multiple of it, in addition to the snapshots the blockchain
+	// saves on its own schedule. A new leader recovers from the
+	// latest saved snapshot by replaying the blocks after it, so a
+	// shorter interval means faster failover but more time and
+	// storage spent saving snapshots. If saving fails, the block
+	// stays committed; the failure is logged and counted in Health
+	// and the generator.checkpoint_failures metric.
+	SnapshotInterval uint64
+
+	// SnapshotPruneInterval, if nonzero, makes the generator compact
+	// the state snapshot of each block whose height is a multiple of
+	// it, before committing the block. The snapshot already drops
+	// spent outputs and expired nonces as each block is applied,
+	// but the map holding the nonce set never gives back the memory
+	// of the nonces removed from it, so over months its footprint
+	// tracks the largest nonce set the blockchain has had rather
+	// than the current one. Compacting copies the nonce set into a
+	// new map of the right size. Unspent outputs can't be pruned:
+	// any later tx may spend them, and the snapshot's state root
+	// commits to them.
+	SnapshotPruneInterval uint64
+
+	// MaxConcurrentBlockReads, if nonzero, limits how many calls
+	// reading committed blocks, such as GetBlocks and StreamBlocks,
+	// may query the database at once. Others wait their turn, or
+	// return ErrTooManyBlockReads if their context is done first.
+	// Peers reach GetBlocks through the get-blocks RPC, and cored
+	// sets this limit from MAX_BLOCK_READS.
+	MaxConcurrentBlockReads int
+
+	// OnLeadershipAcquired, if set, is called when Generate starts,
+	// before it recovers any pending block or makes blocks.
+	// OnLeadershipLost, if set, is called when Generate stops
+	// making blocks, just before it returns and Done is closed.
+	// Both run synchronously in Generate.
+	OnLeadershipAcquired func(ctx context.Context)
+	OnLeadershipLost     func()
+
+	// BlockFetchBatchSize, if nonzero, makes GetBlocks, StreamBlocks
+	// and the other methods that scan a run of blocks read them in
+	// queries of at most this many blocks each, resuming each from
+	// the height the last one reached, instead of in one query, so
+	// a long scan doesn't hold one query open on the database for
+	// its whole length. The driver already streams a query's rows
+	// without a round trip per row, so batching costs a round trip
+	// per batch rather than saving any.
+	BlockFetchBatchSize int
+
+	// BlockCompressionLevel is the gzip level StreamBlocksCompressed
+	// uses, from gzip.BestSpeed to gzip.BestCompression. Zero means
+	// gzip.DefaultCompression.
+	BlockCompressionLevel int
+
+	// AuditSink, if set, receives an AuditRecord for each block
+	// the generator signs, written before the block is committed.
+	// A block that is retried after a failed commit may be recorded
+	// more than once.
+	AuditSink io.Writer
+
+	// AuditKey, if set, is used to sign each audit record.
+	AuditKey ed25519.PrivateKey
+
+	// AuditFatal makes a failure to write an audit record stop the
+	// block from being committed; it stays pending and is retried.
+	// Otherwise the failure is logged and the block is committed.
+	AuditFatal bool
+
+	// DropInvalidTxOnCommit makes the generator drop transactions
+	// that are no longer valid from a pending block left by a
+	// previous leader, and commit a new block with the rest.
+	// Otherwise it fails with ErrBadPendingBlock, with data items
+	// identifying the first invalid transaction and the reason.
+	DropInvalidTxOnCommit bool
+
+	// BlockVersion, if nonzero, is the version the generator stamps
+	// into the blocks it makes, and the highest block version it
+	// understands; the default is version 1. The generator refuses,
+	// with ErrUnsupportedBlockVersion, to build on or commit a block
+	// with a higher version. Block versions can't go down, so an
+	// upgrade is activated by raising BlockVersion on the leader at
+	// an agreed height, after every block signer has been upgraded
+	// to accept the new version; a signer that hasn't been will
+	// refuse to sign, and the generator can't get enough signatures.
+	BlockVersion uint64
+
+	// SignerBreakerThreshold, if nonzero, is the number of times in
+	// a row a block signer can fail before the generator stops
+	// asking it for signatures. After SignerBreakerCooldown, the
+	// generator sends the signer one request at a time until one
+	// succeeds, then goes back to asking it for every block.
+	SignerBreakerThreshold int
+	SignerBreakerCooldown  time.Duration
+
+	// MaxFutureDrift, if nonzero, limits how far ahead of the clock
+	// a block timestamp may be. Timestamps must increase from block
+	// to block, so when the clock is behind the latest block, the
+	// generator uses a timestamp just after that block's instead;
+	// past this limit it returns ErrClockDrift and makes no block.
+	MaxFutureDrift time.Duration
+
+	// TimestampFunc, if set, returns the timestamp for the block
+	// after prev, in place of the clock's current time; for example,
+	// to round timestamps to the second, or take them from an
+	// external time source. As with the clock, a timestamp that
+	// isn't after prev's is replaced by one just after it, and
+	// MaxFutureDrift is measured from the clock.
+	TimestampFunc func(prev *legacy.Block) time.Time
+
+	// Backpressure, if set, is called on each tick of the block
+	// period; when it returns true, Generate skips that tick's block
+	// so that downstream consumers, such as snapshot persistence or
+	// an indexer, can catch up. Skipped ticks aren't made up later;
+	// blocks resume at the normal period once it returns false.
+	Backpressure func() bool
+
+	// Schedule, if set, restricts when Generate makes blocks: on
+	// each tick of the block period, it makes a block only if
+	// Schedule reports true for the current time. The period is
+	// then the finest granularity of the schedule; for example, a
+	// period of an hour and a Schedule that checks for business
+	// hours make a block every hour of the working day and none
+	// overnight. Recovery of a pending block when Generate starts,
+	// and MakeBlock, don't consult Schedule. If Schedule is nil,
+	// Generate makes a block every period.
+	Schedule func(now time.Time) bool
+
+	// LeaderEpoch, if nonzero, is a fencing token for this leader's
+	// term, such as a counter incremented by the leader election
+	// each time it elects a leader. The generator records it in the
+	// database when Generate starts, and again with each block it
+	// commits, in the same statement that stores the block, which
+	// only succeeds if no higher epoch has been recorded. Otherwise
+	// the generator stops with ErrFenced, so a deposed leader that
+	// still believes it leads can't commit blocks over the new
+	// leader's.
+	LeaderEpoch uint64
+
+	// PeerFetch, if set, returns committed blocks after afterHeight,
+	// in height order, from another source of the blockchain, such
+	// as another Chain Core, or none if afterHeight is its latest.
+	// Generate uses it when it starts to fetch and commit any blocks
+	// this process missed before it became leader, so that it
+	// doesn't make a block on a stale tip. If that fails, Generate
+	// returns the error.
+	PeerFetch func(ctx context.Context, afterHeight uint64) ([]*legacy.Block, error)
+
+	// RecoveryVerifyDepth, if nonzero, is the number of recent
+	// blocks Generate checks when it starts: the latest block must
+	// match the committed block at its height, and each of those
+	// blocks must link to the one before it by previous-block hash.
+	// On a mismatch, Generate returns ErrRecoveredTip instead of
+	// building on a tip that may be on the wrong fork.
+	RecoveryVerifyDepth uint64
+
+	// SigningTimeout, if nonzero, is the longest the generator will
+	// wait for signatures on a block. If it doesn't get enough in
+	// that time, it gives up on the block until the next period.
+	// It should be shorter than the block period.
+	SigningTimeout time.Duration
+
+	// SignerHedgeDelay, if nonzero, makes the generator ask only as
+	// many block signers as a block needs at first, choosing the
+	// ones with the lowest average latency in SignerStats, and ask
+	// the rest if those haven't all signed within this time, or as
+	// soon as one of them fails. Otherwise, the generator asks all
+	// signers at once. Either way, it stops waiting, and cancels
+	// outstanding requests, once it has enough signatures. It has
+	// no effect if the block needs every signer. It uses real time,
+	// like SigningTimeout.
+	SignerHedgeDelay time.Duration
+
+	// HeaderOnlySigning makes the generator send block signers
+	// that implement HeaderSigner only the header of each block
+	// to sign, rather than the whole block, which saves bandwidth
+	// for large blocks. Other signers still get the whole block.
+	// Signers that get only the header can't validate the block's
+	// transactions, so this trusts the generator to have done so,
+	// and cored signers only accept headers if SERVE_HEADER_SIGNING
+	// is set.
+	HeaderOnlySigning bool
+
+	// SlowBlockThreshold is how long making a block may take, from
+	// assembly through signing to commit, before MakeBlock and
+	// Generate log a warning with the time spent in each phase.
+	// If zero, it's half the block period; if negative, slow
+	// blocks aren't logged.
+	SlowBlockThreshold time.Duration
+
+	// Clock, if set, replaces the system clock for block
+	// timestamps, the block period ticker, and the times and
+	// durations the generator records, so tests can control them.
+	// SigningTimeout and retry waits still use real time.
+	Clock Clock
+
+	// EnableMetrics turns on publishing block production metrics
+	// as expvars: counts of blocks made and failed attempts, the
+	// chain height, seconds since the latest block, the number of
+	// pending transactions and of expired ones (see MaxTxAge),
+	// per-signer signing latency, and the signer quorum margin:
+	// how many more block signers are reachable than a block needs.
+	EnableMetrics bool
+
+	// EnableTracing turns on recording traces, viewable at
+	// /debug/requests, of making each block, signing it (with one
+	// trace per signer request, titled with the signer's URL), and
+	// committing it.
+	EnableTracing bool
+
 	// config
-	db      pg.DB
-	chain   *protocol.Chain
-	signers []BlockSigner
+	db       pg.DB
+	store    *txdb.Store // reads blocks and snapshots from db
+	chain    *protocol.Chain
+	observer bool // set by NewObserver
+
+	signersMu sync.Mutex
+	signers   []BlockSigner
+	breakers  []*breaker     // one per signer
+	stats     []*signerStats // one per signer
+
+	makeMu sync.Mutex // serializes block production
+
+	submitLimiterOnce sync.Once
+	submitLimiter     *limit.BucketLimiter
+
+	recentMu sync.Mutex
+	recent   *lru.Cache // recently accepted tx ID -> time.Time
+
+	keysMu sync.Mutex // serializes SubmitWithKey
+	keys   *lru.Cache // idempotency key -> keyedTx
+
+	mu           sync.Mutex
+	pool         []*legacy.Tx          // in topological order
+	poolTimes    map[bc.Hash]time.Time // arrival time of each pending tx
+	prunedHeight uint64                // blocks up to this height are pruned from pool
+
+	paused int32 // accessed atomically; nonzero while paused
+
+	// for Health; accessed atomically
+	running          int32 // nonzero while Generate runs
+	signersReachable int32 // valid signatures for the last signed block
+	quorumWarned     int32 // nonzero once the quorum margin warning is logged
+	checkpointFails  int32 // snapshot checkpoints failed since the last one saved
+
+	doneMu sync.Mutex
+	done   chan struct{} // closed when Generate returns
+
+	blockReadsOnce sync.Once
+	blockReads     chan struct{} // one value per block read in progress
+
+	auditMu sync.Mutex
+
+	commitQueueOnce sync.Once
+	commitQueue     chan committedBlock // consumed by runCommitCallbacks
+
+	txQueueOnce sync.Once
+	txQueue     chan acceptedTx // consumed by runTxCallbacks
+
+	tipMu       sync.Mutex
+	tipBlock    *legacy.Block // set by SetTip
+	tipSnapshot *state.Snapshot
+
+	recoveryMu   sync.Mutex
+	lastRecovery *Recovery
 
-	mu         sync.Mutex
-	pool       []*legacy.Tx // in topological order
-	poolHashes map[bc.Hash]bool
+	pendingMu    sync.Mutex
+	pendingBlock *legacy.Block // the pending block, if db is nil
+
+	periodMu      sync.Mutex
+	period        time.Duration
+	periodChanged chan struct{} // signaled by SetPeriod
 }
 
-// New creates and initializes a new Generator.
+// New creates and initializes a new Generator. If db is nil, the
+// generator keeps its pending block in memory, and a block left
+// pending when the process exits is lost rather than recovered.
+// Without a database, the methods that read committed blocks,
+// GetBlockSummaries, RestoreSnapshot and PingDB return ErrNoDB,
+// and so does committing a block with LeaderEpoch set.
 func New(
 	c *protocol.Chain,
 	s []BlockSigner,
 	db pg.DB,
 ) *Generator {
+	var store *txdb.Store
+	if db != nil {
+		store = txdb.NewStore(db)
+	}
 	return &Generator{
-		db:         db,
-		chain:      c,
-		signers:    s,
-		poolHashes: make(map[bc.Hash]bool),
+		db:        db,
+		store:     store,
+		chain:     c,
+		signers:   s,
+		breakers:  newBreakers(len(s)),
+		stats:     newSignerStats(len(s)),
+		poolTimes: make(map[bc.Hash]time.Time),
+
+		periodChanged: make(chan struct{}, 1),
+		done:          closedChan(),
 	}
 }
 
+func closedChan() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// Done returns a channel that's closed when the most recently
+// started call to Generate has returned, including finishing any
+// block it was in the middle of committing. If Generate has never
+// been called, the channel is already closed.
+func (g *Generator) Done() <-chan struct{} {
+	g.doneMu.Lock()
+	defer g.doneMu.Unlock()
+	return g.done
+}
+
+// Period returns the current interval between blocks.
+func (g *Generator) Period() time.Duration {
+	g.periodMu.Lock()
+	defer g.periodMu.Unlock()
+	return g.period
+}
+
+// SetPeriod changes the interval between blocks. If Generate is
+// running, it will make its next block d after the change.
+// It is safe to call SetPeriod concurrently with Generate.
+func (g *Generator) SetPeriod(d time.Duration) error {
+	if d <= 0 {
+		return ErrBadPeriod
+	}
+	g.periodMu.Lock()
+	g.period = d
+	g.periodMu.Unlock()
+
+	select {
+	case g.periodChanged <- struct{}{}:
+	default:
+		// Generate hasn't noticed the last change yet;
+		// it will read the new period when it does.
+	}
+	return nil
+}
+
+func (g *Generator) signerState() ([]BlockSigner, []*breaker, []*signerStats) {
+	g.signersMu.Lock()
+	defer g.signersMu.Unlock()
+	return g.signers, g.breakers, g.stats
+}
+
+// UpdateSigners replaces the generator's block signers, for
+// example after a signer's key is rotated. Blocks already being
+// signed keep using the old signers; the next block uses s.
+// It returns ErrTooFewSigners, leaving the signers unchanged,
+// if s has fewer signers than the latest block's consensus
+// program requires.
+func (g *Generator) UpdateSigners(s []BlockSigner) error {
+	if latest, _ := g.latest(); latest != nil {
+		_, quorum, err := vmutil.ParseBlockMultiSigProgram(latest.ConsensusProgram)
+		if err != nil {
+			return errors.Wrap(err, "parsing consensus program")
+		}
+		if len(s) < quorum {
+			return errors.WithDetailf(ErrTooFewSigners, "got %d signers, need %d", len(s), quorum)
+		}
+	}
+
+	s = append([]BlockSigner(nil), s...)
+	g.signersMu.Lock()
+	g.signers = s
+	g.breakers = newBreakers(len(s))
+	g.stats = newSignerStats(len(s))
+	g.signersMu.Unlock()
+	return nil
+}
+
+// LatestBlock returns the most recent block in the generator's
+// blockchain, or nil if there is none, or the block set by SetTip.
+// It is safe to call concurrently with Generate.
+func (g *Generator) LatestBlock() *legacy.Block {
+	b, _ := g.latest()
+	return b
+}
+
+// LatestSnapshot returns the state snapshot as of LatestBlock.
+// The caller must not modify it; use state.Copy to get a
+// snapshot that can be changed.
+func (g *Generator) LatestSnapshot() *state.Snapshot {
+	_, s := g.latest()
+	return s
+}
+
+// Pause stops Generate from making blocks until Resume is called.
+// While paused, the generator still accepts transactions into the
+// pending tx pool, and MakeBlock still makes blocks on demand.
+func (g *Generator) Pause() {
+	atomic.StoreInt32(&g.paused, 1)
+}
+
+// Resume undoes Pause.
+func (g *Generator) Resume() {
+	atomic.StoreInt32(&g.paused, 0)
+}
+
+// IsPaused reports whether Generate is paused.
+func (g *Generator) IsPaused() bool {
+	return atomic.LoadInt32(&g.paused) != 0
+}
+
 // PendingTxs returns all of the pendings txs that will be
 // included in the generator's next block.
 func (g *Generator) PendingTxs() []*legacy.Tx {
@@ -62,41 +653,338 @@ func (g *Generator) PendingTxs() []*legacy.Tx {
 	return txs
 }
 
-// Submit adds a new pending tx to the pending tx pool.
-func (g *Generator) Submit(ctx context.Context, tx *legacy.Tx) error {
+// A PendingTx is a transaction in the pending tx pool.
+type PendingTx struct {
+	Tx       *legacy.Tx
+	Size     int64     // serialized size in bytes
+	Received time.Time // when it was submitted
+}
+
+// PendingTxInfo is like PendingTxs, but also reports the size and
+// arrival time of each pending tx.
+func (g *Generator) PendingTxInfo() ([]PendingTx, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
-	if g.poolHashes[tx.ID] {
-		return nil
+	ptxs := make([]PendingTx, 0, len(g.pool))
+	for _, tx := range g.pool {
+		n, err := tx.WriteTo(ioutil.Discard)
+		if err != nil {
+			return nil, errors.Wrap(err, "serializing tx")
+		}
+		ptxs = append(ptxs, PendingTx{Tx: tx, Size: n, Received: g.poolTimes[tx.ID]})
 	}
+	return ptxs, nil
+}
+
+// SubmitStatus describes the outcome of submitting a tx.
+type SubmitStatus int
 
-	g.poolHashes[tx.ID] = true
+const (
+	// Accepted means the tx was added to the pending tx pool.
+	Accepted SubmitStatus = iota
+
+	// Duplicate means the tx was already in the pending tx pool.
+	Duplicate
+
+	// Rejected means the tx was not added to the pending tx pool.
+	Rejected
+)
+
+func (s SubmitStatus) String() string {
+	switch s {
+	case Accepted:
+		return "accepted"
+	case Duplicate:
+		return "duplicate"
+	case Rejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("SubmitStatus(%d)", int(s))
+}
+
+// SubmitResult is the result of submitting a tx.
+type SubmitResult struct {
+	ID     bc.Hash
+	Status SubmitStatus
+}
+
+// Submit adds a new pending tx to the pending tx pool.
+// Submitting a tx that's already pending has no effect.
+// An observer, which never makes blocks to drain the pool,
+// returns ErrObserver instead.
+func (g *Generator) Submit(ctx context.Context, tx *legacy.Tx) error {
+	_, err := g.SubmitTx(ctx, tx)
+	return err
+}
+
+// SubmitTx is like Submit, but also reports the ID of the tx and
+// whether it was newly accepted. A Duplicate result is not an error.
+// A Rejected result comes with an error saying why.
+func (g *Generator) SubmitTx(ctx context.Context, tx *legacy.Tx) (SubmitResult, error) {
+	res := SubmitResult{ID: tx.ID}
+	if err := g.checkProducer(); err != nil {
+		res.Status = Rejected
+		return res, err
+	}
+	if !g.allowSubmit(ctx) {
+		res.Status = Rejected
+		return res, ErrRateLimited
+	}
+	if g.recentlySubmitted(tx.ID) {
+		res.Status = Duplicate
+		return res, nil
+	}
+	if err := g.checkTxMaxTime(tx); err != nil {
+		res.Status = Rejected
+		return res, err
+	}
+	if g.TxValidator != nil {
+		if err := g.TxValidator(ctx, tx); err != nil {
+			res.Status = Rejected
+			return res, err
+		}
+	}
+
+	var err error
+	if g.TxSource != nil {
+		err = g.addToSource(ctx, tx)
+	} else {
+		g.mu.Lock()
+		err = g.addTx(tx)
+		g.mu.Unlock()
+	}
+	switch err {
+	case nil:
+		res.Status = Accepted
+		g.txAccepted(ctx, tx)
+	case ErrDuplicateTx:
+		res.Status = Duplicate
+	default:
+		res.Status = Rejected
+		return res, err
+	}
+	return res, nil
+}
+
+// SubmitBatch adds txs to the pending tx pool. It returns one
+// error per tx, in order, with a nil entry for each tx that was
+// accepted. A tx that's already pending, including one earlier in
+// the same batch, gets ErrDuplicateTx. The second result reports
+// failures that affect the whole batch, such as ErrObserver.
+func (g *Generator) SubmitBatch(ctx context.Context, txs []*legacy.Tx) ([]error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := g.checkProducer(); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(txs))
+	for i, tx := range txs {
+		if !g.allowSubmit(ctx) {
+			errs[i] = ErrRateLimited
+		} else if g.recentlySubmitted(tx.ID) {
+			errs[i] = ErrDuplicateTx
+		} else if err := g.checkTxMaxTime(tx); err != nil {
+			errs[i] = err
+		} else if g.TxValidator != nil {
+			errs[i] = g.TxValidator(ctx, tx)
+		}
+	}
+
+	if g.TxSource != nil {
+		for i, tx := range txs {
+			if errs[i] == nil {
+				errs[i] = g.addToSource(ctx, tx)
+			}
+		}
+	} else {
+		g.mu.Lock()
+		for i, tx := range txs {
+			if errs[i] == nil {
+				errs[i] = g.addTx(tx)
+			}
+		}
+		g.mu.Unlock()
+	}
+
+	for i, tx := range txs {
+		if errs[i] == nil {
+			g.txAccepted(ctx, tx)
+		}
+	}
+	return errs, nil
+}
+
+// allowSubmit reports whether the client making the request in ctx
+// may submit another tx under SubmitRateLimit.
+func (g *Generator) allowSubmit(ctx context.Context) bool {
+	if g.SubmitRateLimit <= 0 {
+		return true
+	}
+	g.submitLimiterOnce.Do(func() {
+		burst := g.SubmitRateBurst
+		if burst <= 0 {
+			burst = g.SubmitRateLimit
+		}
+		g.submitLimiter = limit.NewBucketLimiter(g.SubmitRateLimit, burst)
+	})
+	return g.submitLimiter.Allow(authn.Token(ctx))
+}
+
+// addTx adds tx to the pending tx pool.
+// The caller must hold g.mu.
+func (g *Generator) addTx(tx *legacy.Tx) error {
+	if _, ok := g.poolTimes[tx.ID]; ok {
+		return ErrDuplicateTx
+	}
+	if g.MaxPendingTxs > 0 && len(g.pool) >= g.MaxPendingTxs {
+		return errors.WithDetailf(ErrMempoolFull, "%d transactions are pending", len(g.pool))
+	}
+	if err := g.checkTxSize(tx); err != nil {
+		return err
+	}
+
+	if len(g.pool) == 0 && g.chain != nil {
+		// Any block that could commit tx comes after the latest.
+		if latest, _ := g.latest(); latest != nil {
+			g.prunedHeight = latest.Height
+		}
+	}
+	g.poolTimes[tx.ID] = g.now()
 	g.pool = append(g.pool, tx)
+	g.recordPending()
+	g.noteSubmitted(tx.ID)
 	return nil
 }
 
 // Generate runs in a loop, making one new block
-// every block period. It returns when its context
-// is canceled.
+// every block period. It returns nil when its context
+// is canceled. If that happens while a block is being
+// made, Generate finishes committing the block if it has
+// already been signed, and otherwise leaves it pending
+// for the next leader; see also Done.
+// Before starting the loop, it catches up from PeerFetch,
+// if set, and commits any block left pending by a previous
+// leader; see LastRecovery.
 // After each attempt to make a block, it calls health
 // to report either an error or nil to indicate success.
+// Such errors are logged and Generate tries again in the
+// next period, except for errors that prevent it from
+// making any progress, such as ErrBadPendingBlock, ErrFenced,
+// ErrPendingBlockGap, ErrRecoveredTip and ErrBadPeriod, which
+// Generate returns instead, leaving the caller to decide whether
+// to exit or retry.
+//
+// The block period starts out as period, or if period is
+// zero, the period set by WithPeriod or SetPeriod, and
+// may be changed while Generate runs with SetPeriod.
+// Generate skips making blocks while paused; see Pause.
+// It also skips a block when Backpressure reports true.
 func (g *Generator) Generate(
 	ctx context.Context,
 	period time.Duration,
 	health func(error),
-) {
-	ticks := time.Tick(period)
+) error {
+	if err := g.checkProducer(); err != nil {
+		health(err)
+		return err
+	}
+	if period == 0 {
+		period = g.Period()
+	}
+	if period <= 0 {
+		health(ErrBadPeriod)
+		return ErrBadPeriod
+	}
+	g.periodMu.Lock()
+	g.period = period
+	g.periodMu.Unlock()
+
+	if g.SigningTimeout >= period {
+		log.Error(ctx, fmt.Errorf("signing timeout %s is not shorter than block period %s", g.SigningTimeout, period))
+	}
+
+	done := make(chan struct{})
+	g.doneMu.Lock()
+	g.done = done
+	g.doneMu.Unlock()
+	defer close(done)
+
+	defer func() {
+		log.Printkv(ctx, log.KeyMessage, "lost generator leadership")
+		if g.OnLeadershipLost != nil {
+			g.OnLeadershipLost()
+		}
+	}()
+	atomic.StoreInt32(&g.running, 1)
+	defer atomic.StoreInt32(&g.running, 0)
+
+	log.Printkv(ctx, log.KeyMessage, "acquired generator leadership", "period", period)
+	if g.OnLeadershipAcquired != nil {
+		g.OnLeadershipAcquired(ctx)
+	}
+
+	err := g.catchUp(ctx)
+	if err != nil {
+		health(err)
+		return err
+	}
+
+	latest, _ := g.latest()
+	g.recordBlock(latest)
+
+	err = g.verifyRecentBlocks(ctx, latest)
+	if err != nil {
+		health(err)
+		return err
+	}
+	err = g.claimEpoch(ctx)
+	if err != nil {
+		health(err)
+		return err
+	}
+
+	// This process just became leader, so it's responsible for
+	// committing any block the previous leader generated.
+	err = g.recoverPendingBlock(ctx)
+	if err != nil {
+		health(err)
+		if root := errors.Root(err); root == ErrBadPendingBlock || root == ErrFenced || root == ErrPendingBlockGap {
+			return err
+		}
+		log.Printkv(ctx, log.KeyError, err, "height", g.nextHeight())
+	}
+
+	ticker := g.clock().Ticker(period)
+	defer func() { ticker.Stop() }()
 	for {
 		select {
 		case <-ctx.Done():
 			log.Printf(ctx, "Deposed, Generate exiting")
-			return
-		case <-ticks:
-			err := g.makeBlock(ctx)
+			return nil
+		case <-g.periodChanged:
+			ticker.Stop()
+			ticker = g.clock().Ticker(g.Period())
+		case <-ticker.C():
+			if g.IsPaused() {
+				continue
+			}
+			if g.Schedule != nil && !g.Schedule(g.now()) {
+				continue
+			}
+			if g.Backpressure != nil && g.Backpressure() {
+				log.Printkv(ctx, log.KeyMessage, "skipping block for backpressure", "height", g.nextHeight())
+				continue
+			}
+			_, err := g.makeBlock(ctx, false)
 			health(err)
+			if root := errors.Root(err); root == ErrBadPendingBlock || root == ErrFenced {
+				return err
+			}
 			if err != nil {
-				log.Error(ctx, err)
+				log.Printkv(ctx, log.KeyError, err, "height", g.nextHeight())
 			}
 		}
 	}
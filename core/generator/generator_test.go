@@ -1,18 +1,24 @@
 package generator
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"chain/crypto/ed25519"
 	"chain/database/pg/pgtest"
+	"chain/errors"
 	"chain/protocol"
 	"chain/protocol/bc/bctest"
 	"chain/protocol/bc/legacy"
 	"chain/protocol/prottest"
+	"chain/protocol/prottest/memstore"
+	"chain/protocol/state"
 	"chain/testutil"
 )
 
@@ -51,6 +57,297 @@ func TestGeneratorRecovery(t *testing.T) {
 	}
 }
 
+func TestRecoverPendingBlock(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	b, s := c.State()
+
+	pendingBlock, _, err := c.GenerateBlock(ctx, b, s, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = savePendingBlock(ctx, dbtx, pendingBlock)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	g := New(c, nil, dbtx)
+	err = g.recoverPendingBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	rec := g.LastRecovery()
+	if rec == nil {
+		t.Fatal("LastRecovery() = nil after recovering a pending block")
+	}
+	if rec.Hash != pendingBlock.Hash() || rec.Height != pendingBlock.Height || !rec.Committed {
+		t.Errorf("LastRecovery() = %+v, want committed block %d %x", rec, pendingBlock.Height, pendingBlock.Hash().Bytes())
+	}
+	if h := c.Height(); h != pendingBlock.Height {
+		t.Errorf("chain height = %d, want %d", h, pendingBlock.Height)
+	}
+}
+
+func TestRecoverPendingBlockStaleOrGapped(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	b, s := c.State()
+	pendingBlock, _, err := c.GenerateBlock(ctx, b, s, time.Now(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// Another block was committed at the pending block's height.
+	dbtx := pgtest.NewTx(t)
+	err = savePendingBlock(ctx, dbtx, pendingBlock)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, dbtx)
+	err = g.recoverPendingBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if rec := g.LastRecovery(); rec == nil || !rec.Stale || rec.Committed {
+		t.Errorf("LastRecovery() = %+v, want a stale block", rec)
+	}
+
+	// The pending block is ahead of the blockchain.
+	gapped := *pendingBlock
+	gapped.Height = c.Height() + 2
+	dbtx = pgtest.NewTx(t)
+	err = savePendingBlock(ctx, dbtx, &gapped)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	g = New(c, nil, dbtx)
+	err = g.recoverPendingBlock(ctx)
+	if errors.Root(err) != ErrPendingBlockGap {
+		t.Errorf("recoverPendingBlock() = %v, want %v", err, ErrPendingBlockGap)
+	}
+	if rec := g.LastRecovery(); rec == nil || errors.Root(rec.Err) != ErrPendingBlockGap {
+		t.Errorf("LastRecovery() = %+v, want error %v", rec, ErrPendingBlockGap)
+	}
+
+	// Generate stops rather than failing every block period.
+	err = New(c, nil, dbtx).Generate(ctx, time.Second, func(error) {})
+	if errors.Root(err) != ErrPendingBlockGap {
+		t.Errorf("Generate() = %v, want %v", err, ErrPendingBlockGap)
+	}
+}
+
+func TestVerifyRecentBlocks(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	c := prottest.NewChain(t, prottest.WithStore(store))
+	prottest.MakeBlock(t, c, nil)
+	prottest.MakeBlock(t, c, nil)
+	latest, _ := c.State()
+
+	g := New(c, nil, nil)
+	g.RecoveryVerifyDepth = 3
+	err := g.verifyRecentBlocks(ctx, latest)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// The recovered block is on a different fork.
+	forked := *latest
+	forked.TimestampMS++
+	err = g.verifyRecentBlocks(ctx, &forked)
+	if errors.Root(err) != ErrRecoveredTip {
+		t.Errorf("verifyRecentBlocks(forked block) = %v, want %v", err, ErrRecoveredTip)
+	}
+
+	// Generate refuses to build on it.
+	g.tipBlock = &forked
+	var healthErr error
+	err = g.Generate(ctx, time.Second, func(err error) { healthErr = err })
+	if errors.Root(err) != ErrRecoveredTip || errors.Root(healthErr) != ErrRecoveredTip {
+		t.Errorf("Generate() = %v, health(%v), want %v", err, healthErr, ErrRecoveredTip)
+	}
+
+	// A committed block doesn't link to its predecessor.
+	b2 := *store.Blocks[2]
+	b2.TimestampMS++
+	store.Blocks[2] = &b2
+	err = g.verifyRecentBlocks(ctx, latest)
+	if errors.Root(err) != ErrRecoveredTip {
+		t.Errorf("verifyRecentBlocks(mismatched chain) = %v, want %v", err, ErrRecoveredTip)
+	}
+	g.RecoveryVerifyDepth = 1
+	err = g.verifyRecentBlocks(ctx, latest)
+	if err != nil {
+		t.Errorf("verifyRecentBlocks(depth 1) = %v, want nil", err)
+	}
+}
+
+func TestGenerateDone(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	c := prottest.NewChain(t)
+	g := New(c, nil, dbtx)
+	select {
+	case <-g.Done():
+	default:
+		t.Fatal("Done() before Generate is not closed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go g.Generate(ctx, 10*time.Millisecond, func(error) {})
+	for atomic.LoadInt32(&g.running) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	done := g.Done()
+	select {
+	case <-done:
+		t.Fatal("Done() closed while Generate is running")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Done() not closed after Generate returned")
+	}
+}
+
+func TestGenerateBackpressure(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	c := prottest.NewChain(t)
+	clock := &fakeClock{now: time.Now()}
+	g := New(c, nil, dbtx)
+	g.Clock = clock
+	var pressure int32 = 1
+	checked := make(chan struct{}, 1)
+	g.Backpressure = func() bool {
+		checked <- struct{}{}
+		return atomic.LoadInt32(&pressure) != 0
+	}
+	made := make(chan error, 10)
+
+	const period = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go g.Generate(ctx, period, func(err error) { made <- err })
+	for {
+		clock.mu.Lock()
+		n := len(clock.tickers)
+		clock.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		clock.advance(period)
+		<-checked
+	}
+	select {
+	case <-made:
+		t.Fatal("made a block under backpressure")
+	default:
+	}
+
+	atomic.StoreInt32(&pressure, 0)
+	clock.advance(period)
+	<-checked
+	select {
+	case <-made:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block made after backpressure cleared")
+	}
+	select {
+	case <-made:
+		t.Error("made more than one block for one period")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestGenerateSchedule(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	c := prottest.NewChain(t)
+	clock := &fakeClock{now: time.Now()}
+	g := New(c, nil, dbtx)
+	g.Clock = clock
+	var open int32
+	checked := make(chan struct{}, 1)
+	g.Schedule = func(now time.Time) bool {
+		if !now.Equal(clock.Now()) {
+			t.Errorf("Schedule got time %s, want %s", now, clock.Now())
+		}
+		checked <- struct{}{}
+		return atomic.LoadInt32(&open) != 0
+	}
+	made := make(chan error, 10)
+
+	const period = time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go g.Generate(ctx, period, func(err error) { made <- err })
+	for {
+		clock.mu.Lock()
+		n := len(clock.tickers)
+		clock.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 0; i < 3; i++ {
+		clock.advance(period)
+		<-checked
+	}
+	select {
+	case <-made:
+		t.Fatal("made a block outside the schedule")
+	default:
+	}
+
+	atomic.StoreInt32(&open, 1)
+	clock.advance(period)
+	<-checked
+	select {
+	case <-made:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block made during the schedule")
+	}
+}
+
+func TestLeadershipHooks(t *testing.T) {
+	dbtx := pgtest.NewTx(t)
+	c := prottest.NewChain(t)
+	g := New(c, nil, dbtx)
+	acquired := make(chan bool, 1)
+	lost := make(chan bool, 1)
+	g.OnLeadershipAcquired = func(context.Context) { acquired <- true }
+	g.OnLeadershipLost = func() {
+		lost <- atomic.LoadInt32(&g.running) == 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go g.Generate(ctx, 10*time.Millisecond, func(error) {})
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnLeadershipAcquired not called")
+	}
+
+	cancel()
+	select {
+	case notRunning := <-lost:
+		if !notRunning {
+			t.Error("OnLeadershipLost called while Generate still reports running")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnLeadershipLost not called after Generate was canceled")
+	}
+}
+
 func TestGeneratorSignatureFailures(t *testing.T) {
 	ctx := context.Background()
 	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
@@ -88,6 +385,125 @@ func TestGeneratorSignatureFailures(t *testing.T) {
 	}
 }
 
+func TestMakeBlock(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, pgtest.NewTx(t))
+
+	_, err := g.MakeBlock(ctx)
+	if err != ErrNoTxs {
+		t.Fatalf("MakeBlock with empty pool = %v, want %v", err, ErrNoTxs)
+	}
+
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	err = g.Submit(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	height := c.Height()
+	b, err := g.MakeBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if b.Height != height+1 || c.Height() != height+1 {
+		t.Errorf("got block height %d, chain height %d, want %d", b.Height, c.Height(), height+1)
+	}
+	if len(b.Transactions) != 1 || b.Transactions[0].ID != tx.ID {
+		t.Errorf("got block transactions %v, want [%x]", b.Transactions, tx.ID.Bytes())
+	}
+}
+
+func TestOnBlockCommit(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tip, snapshot := c.State()
+	block, s, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	var got *legacy.Block
+	g := New(c, nil, nil)
+	g.OnBlockCommit = func(ctx context.Context, b *legacy.Block, s *state.Snapshot) error {
+		got = b
+		return errors.New("hook failed")
+	}
+	err = g.commitBlock(ctx, block, s, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if got != block {
+		t.Errorf("OnBlockCommit got block %v, want %v", got, block)
+	}
+	if h := c.Height(); h != block.Height {
+		t.Errorf("chain height = %d, want %d even though the hook failed", h, block.Height)
+	}
+}
+
+func TestCommitBlockTwice(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tip, snapshot := c.State()
+	block, s, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	g := New(c, nil, nil)
+	err = g.commitBlock(ctx, block, s, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.commitBlock(ctx, block, s, tip)
+	if errors.Root(err) != ErrStaleBlock {
+		t.Errorf("second commitBlock = %v, want %v", err, ErrStaleBlock)
+	}
+	if h := c.Height(); h != block.Height {
+		t.Errorf("chain height = %d, want %d", h, block.Height)
+	}
+}
+
+func TestLatestBlock(t *testing.T) {
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	b := prottest.MakeBlock(t, c, nil)
+
+	if got := g.LatestBlock(); got.Hash() != b.Hash() {
+		t.Errorf("LatestBlock() = %x, want %x", got.Hash().Bytes(), b.Hash().Bytes())
+	}
+	if _, s := c.State(); g.LatestSnapshot() != s {
+		t.Error("LatestSnapshot() isn't the chain's current snapshot")
+	}
+}
+
+func TestAssembleBlock(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	g := New(c, nil, nil)
+	err := g.Submit(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	b, s, err := g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(b.Transactions) != 1 || b.Transactions[0].ID != tx.ID {
+		t.Errorf("assembled block has %d txs, want only the pending tx", len(b.Transactions))
+	}
+	if b.Height != 2 || s == nil {
+		t.Errorf("got block height %d and snapshot %v, want height 2 and a snapshot", b.Height, s)
+	}
+	if h := c.Height(); h != 1 {
+		t.Errorf("chain height = %d after AssembleBlock, want 1", h)
+	}
+	if n := len(g.PendingTxs()); n != 1 {
+		t.Errorf("got %d pending txs after AssembleBlock, want 1", n)
+	}
+}
+
 func TestGetAndAddBlockSignatures(t *testing.T) {
 	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
 	pubkeys, privkeys := prottest.BlockKeyPairs(c)
@@ -116,8 +532,237 @@ func TestGetAndAddBlockSignatures(t *testing.T) {
 	}
 }
 
+func TestGetAndAddBlockSignaturesInvalid(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, _ := prottest.BlockKeyPairs(c)
+	_, wrongKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The signer claims the chain's block key but signs with another.
+	g := New(c, []BlockSigner{testSigner{nil, pubkeys[0], wrongKey}}, nil)
+
+	ctx := context.Background()
+	tip, snapshot := c.State()
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if errors.Root(err) != ErrBadSignature {
+		t.Fatalf("getAndAddBlockSignatures = %v, want %v", err, ErrBadSignature)
+	}
+	if len(block.Witness) != 0 {
+		t.Errorf("block witness = %x, want empty", block.Witness)
+	}
+}
+
+func TestUpdateSigners(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	_, wrongKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := New(c, []BlockSigner{testSigner{nil, pubkeys[0], wrongKey}}, nil)
+
+	err = g.UpdateSigners(nil)
+	if errors.Root(err) != ErrTooFewSigners {
+		t.Fatalf("UpdateSigners(nil) = %v, want %v", err, ErrTooFewSigners)
+	}
+	if len(g.Signers()) != 1 {
+		t.Fatalf("got %d signers after failed update, want 1", len(g.Signers()))
+	}
+
+	err = g.UpdateSigners([]BlockSigner{testSigner{nil, pubkeys[0], privkeys[0]}})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	ctx := context.Background()
+	tip, snapshot := c.State()
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.ValidateBlock(block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+// TestGetAndAddBlockSignaturesQuorum tests that a block can be signed
+// when some, but not too many, of the signers are unavailable.
+func TestGetAndAddBlockSignaturesQuorum(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(2, 3))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	down := func() error { return errors.New("signer unavailable") }
+
+	ctx := context.Background()
+	tip, snapshot, err := c.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// One of three signers is down; 2-of-3 can still sign.
+	g := New(c, []BlockSigner{
+		testSigner{down, pubkeys[0], privkeys[0]},
+		testSigner{nil, pubkeys[1], privkeys[1]},
+		testSigner{nil, pubkeys[2], privkeys[2]},
+	}, nil)
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.ValidateBlock(block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// Two of three signers are down; the block must not be signed.
+	g = New(c, []BlockSigner{
+		testSigner{down, pubkeys[0], privkeys[0]},
+		testSigner{down, pubkeys[1], privkeys[1]},
+		testSigner{nil, pubkeys[2], privkeys[2]},
+	}, nil)
+	block, _, err = c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	signErr, ok := errors.Root(err).(*SignError)
+	if !ok {
+		t.Fatalf("signing block with 1 of 2 required signatures: got error %v, want a *SignError", err)
+	}
+	if signErr.Obtained != 1 || signErr.Quorum != 2 || len(signErr.Failed) != 2 {
+		t.Errorf("got %+v, want 1 of 2 signatures with 2 failed signers", signErr)
+	}
+	if len(block.Witness) != 0 {
+		t.Errorf("got witness %v after failed signing, want empty", block.Witness)
+	}
+}
+
+func TestGetAndAddBlockSignaturesRetry(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+
+	// Use a signer that fails twice before succeeding.
+	failuresRemaining := int64(2)
+	flaky := func() error {
+		if v := atomic.AddInt64(&failuresRemaining, -1); v >= 0 {
+			return fmt.Errorf("error %d", v)
+		}
+		return nil
+	}
+	g := New(c, []BlockSigner{testSigner{flaky, pubkeys[0], privkeys[0]}}, nil)
+	g.SignerRetries = 2
+	g.SignerRetryBackoff = time.Millisecond
+
+	ctx := context.Background()
+	tip, snapshot, err := c.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.ValidateBlock(block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
 // TestGetAndAddBlockSignaturesRace tests a scenario where all necessary
 // signatures are obtained quickly, but a slow signer is still signing.
+func TestGetAndAddBlockSignaturesPolicy(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(2, 3))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	var attempts int64
+	refuse := func() error {
+		atomic.AddInt64(&attempts, 1)
+		return errors.WithDetail(ErrPolicyRejected, "block has no transactions")
+	}
+
+	ctx := context.Background()
+	tip, snapshot, err := c.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// One signer refuses; the other two are enough.
+	g := New(c, []BlockSigner{
+		testSigner{refuse, pubkeys[0], privkeys[0]},
+		testSigner{nil, pubkeys[1], privkeys[1]},
+		testSigner{nil, pubkeys[2], privkeys[2]},
+	}, nil)
+	g.SignerRetries = 2
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// Two signers refuse, so there can't be a quorum.
+	atomic.StoreInt64(&attempts, 0)
+	block.Witness = nil
+	g = New(c, []BlockSigner{
+		testSigner{refuse, pubkeys[0], privkeys[0]},
+		testSigner{refuse, pubkeys[1], privkeys[1]},
+		testSigner{nil, pubkeys[2], privkeys[2]},
+	}, nil)
+	g.SignerRetries = 2
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if errors.Root(err) != ErrPolicyRejected {
+		t.Errorf("getAndAddBlockSignatures() = %v, want %v", err, ErrPolicyRejected)
+	}
+	if n := atomic.LoadInt64(&attempts); n != 2 {
+		t.Errorf("refusing signers were asked %d times, want 2 (no retries)", n)
+	}
+}
+
+func TestGetAndAddBlockSignaturesTimeout(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	release := make(chan struct{})
+	defer close(release)
+	slow := func() error { <-release; return nil }
+
+	g := New(c, []BlockSigner{testSigner{slow, pubkeys[0], privkeys[0]}}, nil)
+	g.SigningTimeout = 10 * time.Millisecond
+
+	ctx := context.Background()
+	tip, snapshot := c.State()
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if _, ok := errors.Root(err).(*SignError); !ok {
+		t.Fatalf("got error %v, want a *SignError", err)
+	}
+	if !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("got error %q, want it to mention the timeout", err)
+	}
+}
+
 func TestGetAndAddBlockSignaturesRace(t *testing.T) {
 	c := prottest.NewChain(t)
 	pubkey, privkey, err := ed25519.GenerateKey(nil)
@@ -164,6 +809,275 @@ func TestGetAndAddBlockSignaturesInitialBlock(t *testing.T) {
 	}
 }
 
+func TestSetPeriod(t *testing.T) {
+	g := New(nil, nil, nil)
+	if err := g.SetPeriod(0); err != ErrBadPeriod {
+		t.Errorf("SetPeriod(0) = %v, want %v", err, ErrBadPeriod)
+	}
+	if err := g.SetPeriod(-time.Second); err != ErrBadPeriod {
+		t.Errorf("SetPeriod(-1s) = %v, want %v", err, ErrBadPeriod)
+	}
+	if err := g.SetPeriod(2 * time.Second); err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if got := g.Period(); got != 2*time.Second {
+		t.Errorf("Period() = %s, want %s", got, 2*time.Second)
+	}
+}
+
+func TestSubmitTx(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	g := New(c, nil, nil)
+
+	for _, want := range []SubmitStatus{Accepted, Duplicate} {
+		res, err := g.SubmitTx(ctx, tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if res.ID != tx.ID {
+			t.Errorf("SubmitTx ID = %x, want %x", res.ID.Bytes(), tx.ID.Bytes())
+		}
+		if res.Status != want {
+			t.Errorf("SubmitTx status = %s, want %s", res.Status, want)
+		}
+	}
+}
+
+func TestSubmitBatch(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	tx1 := bctest.NewIssuanceTx(t, initial)
+	tx2 := bctest.NewIssuanceTx(t, initial)
+	tx3 := bctest.NewIssuanceTx(t, initial)
+
+	g := New(c, nil, nil)
+	err := g.Submit(ctx, tx1)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{tx1, tx2, tx3, tx2})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	want := []error{ErrDuplicateTx, nil, nil, ErrDuplicateTx}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("SubmitBatch errors = %v, want %v", errs, want)
+	}
+	if n := len(g.PendingTxs()); n != 3 {
+		t.Errorf("got %d pending txs, want 3", n)
+	}
+}
+
+func TestSubmitMaxTxBytes(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	small := bctest.NewIssuanceTx(t, initial)
+	big := bctest.NewIssuanceTx(t, initial, func(tx *legacy.Tx) {
+		tx.ReferenceData = make([]byte, 1000)
+	})
+
+	g := New(c, nil, nil)
+	g.MaxTxBytes = 800
+	err := g.Submit(ctx, big)
+	if errors.Root(err) != ErrTxTooLarge {
+		t.Errorf("Submit(big) = %v, want %v", err, ErrTxTooLarge)
+	}
+
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{small, big})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if errs[0] != nil || errors.Root(errs[1]) != ErrTxTooLarge {
+		t.Errorf("SubmitBatch errors = %v, want [<nil> %v]", errs, ErrTxTooLarge)
+	}
+	if n := len(g.PendingTxs()); n != 1 {
+		t.Errorf("got %d pending txs, want 1", n)
+	}
+}
+
+func TestSubmitMaxPendingTxs(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	var txs []*legacy.Tx
+	for i := 0; i < 3; i++ {
+		txs = append(txs, bctest.NewIssuanceTx(t, initial))
+	}
+
+	g := New(c, nil, nil)
+	g.MaxPendingTxs = 2
+	errs, err := g.SubmitBatch(ctx, txs)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if errs[0] != nil || errs[1] != nil || errors.Root(errs[2]) != ErrMempoolFull {
+		t.Errorf("SubmitBatch errors = %v, want [<nil> <nil> %v]", errs, ErrMempoolFull)
+	}
+
+	// Resubmitting a pending tx is still a duplicate, not an error.
+	res, err := g.SubmitTx(ctx, txs[0])
+	if err != nil || res.Status != Duplicate {
+		t.Errorf("SubmitTx(pending) = %v, %v, want %v, <nil>", res.Status, err, Duplicate)
+	}
+
+	g.mu.Lock()
+	g.takeTxs()
+	g.mu.Unlock()
+	err = g.Submit(ctx, txs[2])
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
+
+func TestSubmitTxValidator(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	good := bctest.NewIssuanceTx(t, initial)
+	bad := bctest.NewIssuanceTx(t, initial, func(tx *legacy.Tx) {
+		tx.ReferenceData = []byte("bad")
+	})
+	errBad := errors.New("bad reference data")
+
+	g := New(c, nil, nil)
+	g.TxValidator = func(ctx context.Context, tx *legacy.Tx) error {
+		if string(tx.ReferenceData) == "bad" {
+			return errBad
+		}
+		return nil
+	}
+	res, err := g.SubmitTx(ctx, bad)
+	if err != errBad || res.Status != Rejected {
+		t.Errorf("SubmitTx(bad) = %v, %v, want %v, %v", res.Status, err, Rejected, errBad)
+	}
+
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{good, bad})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if errs[0] != nil || errs[1] != errBad {
+		t.Errorf("SubmitBatch errors = %v, want [<nil> %v]", errs, errBad)
+	}
+	if n := len(g.PendingTxs()); n != 1 {
+		t.Errorf("got %d pending txs, want 1", n)
+	}
+}
+
+func TestSubmitRateLimit(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	var txs []*legacy.Tx
+	for i := 0; i < 3; i++ {
+		txs = append(txs, bctest.NewIssuanceTx(t, initial))
+	}
+
+	g := New(c, nil, nil)
+	g.SubmitRateLimit = 1
+	g.SubmitRateBurst = 2
+	errs, err := g.SubmitBatch(ctx, txs)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	want := []error{nil, nil, ErrRateLimited}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("SubmitBatch errors = %v, want %v", errs, want)
+	}
+	if n := len(g.PendingTxs()); n != 2 {
+		t.Errorf("got %d pending txs, want 2", n)
+	}
+}
+
+func TestSubmitRecentTxCache(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	g := New(c, nil, nil)
+	g.RecentTxCacheSize = 10
+
+	wantStatus := func(want SubmitStatus) {
+		res, err := g.SubmitTx(ctx, tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if res.Status != want {
+			t.Errorf("SubmitTx status = %s, want %s", res.Status, want)
+		}
+	}
+
+	wantStatus(Accepted)
+
+	// Still a duplicate after leaving the pending tx pool...
+	g.mu.Lock()
+	g.takeTxs()
+	g.mu.Unlock()
+	wantStatus(Duplicate)
+
+	// ...until it's committed.
+	g.forgetSubmitted([]*legacy.Tx{tx})
+	wantStatus(Accepted)
+}
+
+func TestPendingTxInfo(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	g := New(c, nil, nil)
+
+	before := time.Now()
+	err := g.Submit(ctx, tx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	ptxs, err := g.PendingTxInfo()
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(ptxs) != 1 || ptxs[0].Tx != tx {
+		t.Fatalf("PendingTxInfo() = %v, want only the submitted tx", ptxs)
+	}
+	var buf bytes.Buffer
+	tx.WriteTo(&buf)
+	if ptxs[0].Size != int64(buf.Len()) {
+		t.Errorf("Size = %d, want %d", ptxs[0].Size, buf.Len())
+	}
+	if ptxs[0].Received.Before(before) {
+		t.Errorf("Received = %s, want no earlier than %s", ptxs[0].Received, before)
+	}
+}
+
+func TestGenerateBadPeriod(t *testing.T) {
+	var healthErr error
+	err := New(nil, nil, nil).Generate(context.Background(), 0, func(err error) { healthErr = err })
+	if err != ErrBadPeriod {
+		t.Errorf("Generate(0) = %v, want %v", err, ErrBadPeriod)
+	}
+	if healthErr != ErrBadPeriod {
+		t.Errorf("health reported %v, want %v", healthErr, ErrBadPeriod)
+	}
+}
+
+func TestPause(t *testing.T) {
+	g := New(nil, nil, nil)
+	if g.IsPaused() {
+		t.Error("new generator is paused")
+	}
+	g.Pause()
+	if !g.IsPaused() {
+		t.Error("IsPaused() = false after Pause")
+	}
+	g.Resume()
+	if g.IsPaused() {
+		t.Error("IsPaused() = true after Resume")
+	}
+}
+
 type testSigner struct {
 	before  func() error
 	pubKey  ed25519.PublicKey
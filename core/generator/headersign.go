@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+)
+
+// A HeaderSigner is a BlockSigner that can sign a block given
+// only its header, so the generator needn't send it the whole
+// block. It's meant for remote signers of large blocks; see
+// HeaderOnlySigning. The remote signers configured by cored
+// implement it with the sign-block-header RPC, which is served by
+// blocksigner.BlockSigner's ValidateAndSignBlockHeader.
+//
+// SignBlockHeader receives the block header, without the block
+// witness, as produced by legacy.BlockHeader.MarshalText. The
+// header commits to the block's transactions and resulting state
+// through its TransactionsMerkleRoot and AssetsMerkleRoot. It
+// must return an ed25519 signature over the hash of the header,
+// which is BlockSigningHash of the block, and must refuse to
+// sign a different header at the same height, as SignBlock does.
+// The generator checks the signature against BlockSigningHash
+// just as it does for SignBlock.
+type HeaderSigner interface {
+	BlockSigner
+	SignBlockHeader(ctx context.Context, marshalledHeader []byte) (signature []byte, err error)
+}
+
+// marshalHeader returns b's header for HeaderSigners, or nil if
+// HeaderOnlySigning isn't set.
+func (g *Generator) marshalHeader(b *legacy.Block) ([]byte, error) {
+	if !g.HeaderOnlySigning {
+		return nil, nil
+	}
+	h := b.BlockHeader
+	h.Witness = nil
+	marshalledHeader, err := h.MarshalText()
+	return marshalledHeader, errors.Wrap(err, "marshalling block header")
+}
+
+// requestSig asks signer to sign a block, sending it only the
+// block header if it's a HeaderSigner and marshalledHeader isn't
+// nil, and the whole block otherwise.
+func requestSig(ctx context.Context, signer BlockSigner, marshalledBlock, marshalledHeader []byte) ([]byte, error) {
+	if hs, ok := signer.(HeaderSigner); ok && marshalledHeader != nil {
+		return hs.SignBlockHeader(ctx, marshalledHeader)
+	}
+	return signer.SignBlock(ctx, marshalledBlock)
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// headerTestSigner is a HeaderSigner that counts how it was asked
+// to sign.
+type headerTestSigner struct {
+	testSigner
+	blocks, headers *int
+	corrupt         bool // sign the wrong hash
+}
+
+func (s headerTestSigner) SignBlock(ctx context.Context, marshalledBlock []byte) ([]byte, error) {
+	*s.blocks++
+	return s.testSigner.SignBlock(ctx, marshalledBlock)
+}
+
+func (s headerTestSigner) SignBlockHeader(ctx context.Context, marshalledHeader []byte) ([]byte, error) {
+	*s.headers++
+	var h legacy.BlockHeader
+	err := h.UnmarshalText(marshalledHeader)
+	if err != nil {
+		return nil, err
+	}
+	if s.corrupt {
+		h.Height++
+	}
+	return ed25519.Sign(s.privKey, h.Hash().Bytes()), nil
+}
+
+func TestHeaderOnlySigning(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	ctx := context.Background()
+	tip, snapshot, err := c.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	sign := func(headerOnly, corrupt bool) (blocks, headers int, err error) {
+		signer := headerTestSigner{testSigner{nil, pubkeys[0], privkeys[0]}, &blocks, &headers, corrupt}
+		g := New(c, []BlockSigner{signer}, nil)
+		g.HeaderOnlySigning = headerOnly
+		block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = g.getAndAddBlockSignatures(ctx, block, tip)
+		if err == nil {
+			err = VerifyBlockSignatures(block, pubkeys, 1)
+		}
+		return blocks, headers, err
+	}
+
+	blocks, headers, err := sign(false, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if blocks != 1 || headers != 0 {
+		t.Errorf("without HeaderOnlySigning, got %d block and %d header requests, want 1 and 0", blocks, headers)
+	}
+
+	blocks, headers, err = sign(true, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if blocks != 0 || headers != 1 {
+		t.Errorf("with HeaderOnlySigning, got %d block and %d header requests, want 0 and 1", blocks, headers)
+	}
+
+	_, _, err = sign(true, true)
+	if errors.Root(err) != ErrBadSignature {
+		t.Errorf("signature over the wrong header = %v, want %v", err, ErrBadSignature)
+	}
+}
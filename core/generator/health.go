@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/vm/vmutil"
+)
+
+// A HealthStatus reports on the generator's progress.
+type HealthStatus struct {
+	// IsLeader reports whether Generate is running in this process.
+	IsLeader bool
+
+	LastBlockHeight       uint64
+	LastBlockTime         time.Time
+	SecondsSinceLastBlock float64
+
+	// SignersReachable is the number of signers that returned a
+	// valid signature for the most recent block the generator tried
+	// to sign. The generator stops asking once it has enough
+	// signatures, so more signers may be reachable than this.
+	SignersReachable int
+
+	// TrippedSigners lists the signers the generator has stopped
+	// asking for signatures after repeated failures.
+	TrippedSigners []BlockSigner
+
+	// DBReachable reports whether the generator's database answered
+	// PingDB, and DBLatencySeconds how long it took.
+	DBReachable      bool
+	DBLatencySeconds float64
+
+	// SignersUsable is the number of signers that aren't tripped
+	// and whose most recent signing request, if any, got an answer,
+	// and Quorum the number of signatures the next block needs.
+	SignersUsable int
+	Quorum        int
+
+	// CheckpointFailures is the number of snapshot checkpoints
+	// (see SnapshotInterval) that have failed since the last one
+	// that was saved. The blocks are committed regardless, so this
+	// doesn't affect Healthy, but a new leader will have more
+	// blocks to replay.
+	CheckpointFailures int
+
+	// Healthy reports whether the generator is leader, has made
+	// a block within the last three block periods or has no block
+	// due (because it has no pending txs and no empty block is due),
+	// has at least Quorum usable signers, and can reach its database.
+	Healthy bool
+}
+
+// Health reports on the generator's progress. It is cheap enough
+// to use as a liveness or readiness check; it pings the database,
+// but doesn't wait more than a couple of seconds for it.
+func (g *Generator) Health(ctx context.Context) (*HealthStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	hs := &HealthStatus{
+		IsLeader:           atomic.LoadInt32(&g.running) != 0,
+		SignersReachable:   int(atomic.LoadInt32(&g.signersReachable)),
+		CheckpointFailures: int(atomic.LoadInt32(&g.checkpointFails)),
+	}
+	signers, breakers, stats := g.signerState()
+	var untripped []*signerStats
+	for i, b := range breakers {
+		if g.breakerTripped(b) {
+			hs.TrippedSigners = append(hs.TrippedSigners, signers[i])
+			continue
+		}
+		untripped = append(untripped, stats[i])
+	}
+	hs.SignersUsable = reachableSigners(untripped)
+	dbOK := true
+	if g.db != nil {
+		d, err := g.pingDB(ctx)
+		hs.DBReachable = err == nil
+		hs.DBLatencySeconds = d.Seconds()
+		dbOK = hs.DBReachable
+	}
+	if b := g.LatestBlock(); b != nil {
+		hs.LastBlockHeight = b.Height
+		hs.LastBlockTime = b.Time()
+		since := g.since(hs.LastBlockTime)
+		hs.SecondsSinceLastBlock = since.Seconds()
+
+		_, quorum, err := vmutil.ParseBlockMultiSigProgram(b.ConsensusProgram)
+		if err != nil {
+			return nil, errors.Wrap(err, "parsing consensus program")
+		}
+		hs.Quorum = quorum
+		fresh := since < 3*g.Period()
+		if !fresh {
+			n, err := g.pendingCount(ctx)
+			if err != nil {
+				return nil, err
+			}
+			due, _ := g.blockDue(b, n)
+			fresh = !due
+		}
+		hs.Healthy = hs.IsLeader && fresh && hs.SignersUsable >= quorum && dbOK
+	}
+	return hs, nil
+}
+
+// pendingCount returns the number of pending txs, in the pending
+// tx pool or the TxSource.
+func (g *Generator) pendingCount(ctx context.Context) (int, error) {
+	if g.TxSource != nil {
+		txs, err := g.TxSource.Pending(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "getting pending txs")
+		}
+		return len(txs), nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.pool), nil
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestHealth(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	b := prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, nil)
+	g.SetPeriod(time.Minute)
+
+	hs, err := g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.IsLeader || hs.Healthy {
+		t.Errorf("got %+v, want not leader and not healthy before Generate runs", hs)
+	}
+	if hs.LastBlockHeight != b.Height || !hs.LastBlockTime.Equal(b.Time()) {
+		t.Errorf("got last block %d at %s, want %d at %s", hs.LastBlockHeight, hs.LastBlockTime, b.Height, b.Time())
+	}
+
+	atomic.StoreInt32(&g.running, 1) // as if Generate were running
+	hs, err = g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !hs.IsLeader || !hs.Healthy {
+		t.Errorf("got %+v, want a healthy leader", hs)
+	}
+
+	g.SetPeriod(time.Nanosecond)
+	hs, err = g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !hs.Healthy {
+		t.Errorf("got %+v, want healthy while idle with no block due", hs)
+	}
+
+	tx := testTx(1, nil, nil)
+	g.pool = append(g.pool, tx)
+	g.poolTimes[tx.ID] = time.Now()
+	hs, err = g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.Healthy {
+		t.Errorf("got %+v, want unhealthy after three block periods with a block due", hs)
+	}
+}
+
+func TestHealthSigners(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 2))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	prottest.MakeBlock(t, c, nil)
+	g := New(c, []BlockSigner{
+		testSigner{nil, pubkeys[0], privkeys[0]},
+		testSigner{nil, pubkeys[1], privkeys[1]},
+	}, nil)
+	g.SetPeriod(time.Minute)
+	atomic.StoreInt32(&g.running, 1)
+
+	hs, err := g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !hs.Healthy || hs.SignersUsable != 2 || hs.Quorum != 1 {
+		t.Errorf("got %+v, want healthy with 2 usable signers and quorum 1", hs)
+	}
+
+	for _, s := range g.stats {
+		s.record(time.Now(), time.Millisecond, errors.New("unreachable"), false)
+	}
+	hs, err = g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.Healthy || hs.SignersUsable != 0 {
+		t.Errorf("got %+v, want unhealthy with no usable signers", hs)
+	}
+}
+
+// deadDB is a pg.DB whose connection is gone.
+type deadDB struct{ pg.DB }
+
+var errDeadDB = errors.New("connection refused")
+
+func (deadDB) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errDeadDB
+}
+
+func TestHealthDB(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, deadDB{})
+	g.SetPeriod(time.Minute)
+	atomic.StoreInt32(&g.running, 1)
+
+	err := g.PingDB(ctx)
+	if errors.Root(err) != errDeadDB {
+		t.Errorf("PingDB() = %v, want %v", err, errDeadDB)
+	}
+	hs, err := g.Health(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if hs.DBReachable || hs.Healthy {
+		t.Errorf("got %+v, want database unreachable and not healthy", hs)
+	}
+}
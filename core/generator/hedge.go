@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"sort"
+	"time"
+)
+
+// signerOrder returns the indexes of the signers with the given
+// stats, fastest first by average latency. Signers whose last
+// request failed come last, and signers with no requests yet come
+// first, so the generator learns how fast they are.
+func signerOrder(stats []*signerStats) []int {
+	type entry struct {
+		i           int
+		unreachable bool
+		latency     time.Duration
+	}
+	entries := make([]entry, len(stats))
+	for i, s := range stats {
+		s.mu.Lock()
+		e := entry{i: i, unreachable: s.unreachable}
+		if n := s.successes + s.failures; n > 0 {
+			e.latency = s.totalLatency / time.Duration(n)
+		}
+		s.mu.Unlock()
+		entries[i] = e
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].unreachable != entries[b].unreachable {
+			return !entries[a].unreachable
+		}
+		return entries[a].latency < entries[b].latency
+	})
+	order := make([]int, len(entries))
+	for k, e := range entries {
+		order[k] = e.i
+	}
+	return order
+}
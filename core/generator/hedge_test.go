@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSignerOrder(t *testing.T) {
+	stats := newSignerStats(4)
+	now := time.Now()
+	stats[0].record(now, 30*time.Millisecond, nil, false)
+	stats[1].record(now, 10*time.Millisecond, nil, false)
+	stats[2].record(now, time.Millisecond, errors.New("unreachable"), false)
+	// stats[3] has no requests yet
+
+	got := signerOrder(stats)
+	if want := []int{3, 1, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("signerOrder = %v, want %v", got, want)
+	}
+}
+
+func TestSignerHedge(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(2, 3))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	ctx := context.Background()
+	tip, snapshot, err := c.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	var slowCalls int32
+	slow := func() error {
+		atomic.AddInt32(&slowCalls, 1)
+		return nil
+	}
+	sign := func(first func() error) {
+		g := New(c, []BlockSigner{
+			testSigner{first, pubkeys[0], privkeys[0]},
+			testSigner{nil, pubkeys[1], privkeys[1]},
+			testSigner{slow, pubkeys[2], privkeys[2]},
+		}, nil)
+		g.SignerHedgeDelay = time.Minute
+		g.stats[0].record(time.Now(), time.Millisecond, nil, false)
+		g.stats[1].record(time.Now(), time.Millisecond, nil, false)
+		g.stats[2].record(time.Now(), time.Second, nil, false)
+
+		block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = g.getAndAddBlockSignatures(ctx, block, tip)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = c.ValidateBlock(block, tip)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	// The two fast signers are enough.
+	sign(nil)
+	if n := atomic.LoadInt32(&slowCalls); n != 0 {
+		t.Errorf("slow signer got %d requests, want 0", n)
+	}
+
+	// A fast signer fails, so the slow one is asked
+	// without waiting for SignerHedgeDelay.
+	sign(func() error { return errors.New("signer unavailable") })
+	if n := atomic.LoadInt32(&slowCalls); n != 1 {
+		t.Errorf("slow signer got %d requests, want 1", n)
+	}
+}
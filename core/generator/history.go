@@ -0,0 +1,256 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrNoNewBlocks is returned by GetBlocksSince when no new block
+// arrives before its wait time is up.
+var ErrNoNewBlocks = errors.New("no new blocks")
+
+// ErrBlockNotFound is returned by GetBlockByHash when there is
+// no committed block with the given hash.
+var ErrBlockNotFound = errors.New("block not found")
+
+// ErrTooManyBlockReads is returned by the methods that read
+// blocks when ctx is done before another read may start under
+// MaxConcurrentBlockReads.
+var ErrTooManyBlockReads = errors.New("too many concurrent block reads")
+
+// ErrBadBlockRange is returned by GetBlockRange when the range
+// is inverted or has more than MaxBlockRange blocks.
+var ErrBadBlockRange = errors.New("invalid block range")
+
+// DefaultBlocksLimit is the most blocks GetBlocks will return
+// when called with no limit.
+const DefaultBlocksLimit = 1000
+
+// MaxBlockRange is the most blocks GetBlockRange will return
+// in one call.
+const MaxBlockRange = 1000
+
+// GetBlocks returns blocks with heights greater than afterHeight,
+// in height order, waiting if necessary until there is at least one.
+// It returns at most limit blocks, or DefaultBlocksLimit blocks if
+// limit is zero. To page through the rest, callers can call GetBlocks
+// again with the height of the last block returned.
+func (g *Generator) GetBlocks(ctx context.Context, afterHeight uint64, limit int) ([]*legacy.Block, error) {
+	if limit <= 0 {
+		limit = DefaultBlocksLimit
+	}
+	if err := g.checkDB(); err != nil {
+		return nil, err
+	}
+
+	err := <-g.chain.BlockSoonWaiter(ctx, afterHeight+1)
+	if err != nil {
+		return nil, errors.Wrapf(err, "waiting for block at height %d", afterHeight+1)
+	}
+	return g.blocksAfter(ctx, afterHeight, limit)
+}
+
+// GetBlocksSince is like GetBlocks with the default limit, but waits
+// at most maxWait for a new block. If none arrives in that time, it
+// returns an empty slice and ErrNoNewBlocks.
+func (g *Generator) GetBlocksSince(ctx context.Context, afterHeight uint64, maxWait time.Duration) ([]*legacy.Block, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+
+	err := <-g.chain.BlockSoonWaiter(waitCtx, afterHeight+1)
+	if err == context.DeadlineExceeded && ctx.Err() == nil {
+		return []*legacy.Block{}, ErrNoNewBlocks
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "waiting for block at height %d", afterHeight+1)
+	}
+	return g.blocksAfter(ctx, afterHeight, DefaultBlocksLimit)
+}
+
+func (g *Generator) blocksAfter(ctx context.Context, afterHeight uint64, limit int) ([]*legacy.Block, error) {
+	var blocks []*legacy.Block
+	err := g.streamBlocks(ctx, afterHeight, limit, func(b *legacy.Block) error {
+		blocks = append(blocks, b)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// WaitForHeight waits for the block at the given height and
+// returns it. It returns an error if ctx is canceled first.
+func (g *Generator) WaitForHeight(ctx context.Context, height uint64) (*legacy.Block, error) {
+	select {
+	case <-g.chain.BlockWaiter(height):
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "waiting for block at height %d", height)
+	}
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	b, err := g.chain.GetBlock(ctx, height)
+	return b, errors.Wrapf(err, "getting block at height %d", height)
+}
+
+// GetLatestBlocks returns the count most recent blocks, newest
+// first, or DefaultBlocksLimit blocks if count is zero.
+func (g *Generator) GetLatestBlocks(ctx context.Context, count int) ([]*legacy.Block, error) {
+	if count <= 0 {
+		count = DefaultBlocksLimit
+	}
+	if err := g.checkDB(); err != nil {
+		return nil, err
+	}
+
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	blocks, err := g.store.LatestBlocks(ctx, count)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting latest blocks")
+	}
+	return blocks, nil
+}
+
+// GetBlockRange returns the committed blocks with heights from
+// fromHeight through toHeight, in height order. Unlike GetBlocks,
+// it doesn't wait; heights past the latest block are left out.
+// A peer syncing from scratch can request several disjoint ranges
+// concurrently and reassemble them in order. The range may have
+// at most MaxBlockRange blocks.
+func (g *Generator) GetBlockRange(ctx context.Context, fromHeight, toHeight uint64) ([]*legacy.Block, error) {
+	if toHeight < fromHeight {
+		return nil, errors.WithDetailf(ErrBadBlockRange, "height %d is before height %d", toHeight, fromHeight)
+	}
+	if toHeight-fromHeight >= MaxBlockRange {
+		return nil, errors.WithDetailf(ErrBadBlockRange, "range has %d blocks; the limit is %d", toHeight-fromHeight+1, MaxBlockRange)
+	}
+	if err := g.checkDB(); err != nil {
+		return nil, err
+	}
+
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var afterHeight uint64
+	if fromHeight > 0 {
+		afterHeight = fromHeight - 1
+	}
+	blocks := []*legacy.Block{}
+	err = g.store.ListBlocks(ctx, afterHeight, int(toHeight-afterHeight), func(b *legacy.Block) error {
+		blocks = append(blocks, b)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting blocks %d through %d", fromHeight, toHeight)
+	}
+	return blocks, nil
+}
+
+// GetBlockByHash returns the committed block with hash h,
+// or ErrBlockNotFound if there is none.
+func (g *Generator) GetBlockByHash(ctx context.Context, h bc.Hash) (*legacy.Block, error) {
+	if err := g.checkDB(); err != nil {
+		return nil, err
+	}
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	b, err := g.store.GetBlockByHash(ctx, h)
+	if errors.Root(err) == sql.ErrNoRows {
+		return nil, errors.WithDetailf(ErrBlockNotFound, "block hash %x", h.Bytes())
+	}
+	return b, errors.Wrapf(err, "getting block %x", h.Bytes())
+}
+
+// StreamBlocks calls fn on each block with height greater than
+// afterHeight, in height order, without holding them all in memory.
+// It does not wait for new blocks.
+// If fn returns an error or ctx is canceled, StreamBlocks stops and
+// returns an error wrapping it; errors.Root recovers the original.
+func (g *Generator) StreamBlocks(ctx context.Context, afterHeight uint64, fn func(*legacy.Block) error) error {
+	return g.streamBlocks(ctx, afterHeight, 0, fn)
+}
+
+// streamBlocks is like StreamBlocks, but stops after limit blocks
+// if limit is positive.
+func (g *Generator) streamBlocks(ctx context.Context, afterHeight uint64, limit int, fn func(*legacy.Block) error) error {
+	if err := g.checkDB(); err != nil {
+		return err
+	}
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	batch := g.BlockFetchBatchSize
+	if batch <= 0 {
+		return g.scanBlocks(ctx, afterHeight, limit, fn)
+	}
+	for {
+		n := batch
+		if limit > 0 && limit < n {
+			n = limit
+		}
+		var got int
+		err := g.scanBlocks(ctx, afterHeight, n, func(b *legacy.Block) error {
+			got++
+			afterHeight = b.Height
+			return fn(b)
+		})
+		if err != nil || got < n {
+			return err
+		}
+		if limit > 0 {
+			limit -= got
+			if limit == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// scanBlocks calls fn on each block with height greater than
+// afterHeight, in height order, up to limit blocks if limit is
+// positive, in a single query.
+func (g *Generator) scanBlocks(ctx context.Context, afterHeight uint64, limit int, fn func(*legacy.Block) error) error {
+	err := g.store.ListBlocks(ctx, afterHeight, limit, fn)
+	return errors.Wrapf(err, "getting blocks after height %d", afterHeight)
+}
+
+// startBlockRead waits until a block read may start under
+// MaxConcurrentBlockReads, and returns a function to call
+// when it's done.
+func (g *Generator) startBlockRead(ctx context.Context) (release func(), err error) {
+	if g.MaxConcurrentBlockReads <= 0 {
+		return func() {}, nil
+	}
+	g.blockReadsOnce.Do(func() {
+		g.blockReads = make(chan struct{}, g.MaxConcurrentBlockReads)
+	})
+	select {
+	case g.blockReads <- struct{}{}:
+		return func() { <-g.blockReads }, nil
+	case <-ctx.Done():
+		return nil, errors.Sub(ErrTooManyBlockReads, ctx.Err())
+	}
+}
@@ -0,0 +1,306 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"chain/core/txdb"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestGetBlocksLimit(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	for i := 0; i < 4; i++ {
+		prottest.MakeBlock(t, c, nil)
+	}
+	g := New(c, nil, db)
+
+	// The chain has blocks 1 through 5.
+	blocks, err := g.GetBlocks(ctx, 1, 3)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("got %d blocks, want 3", len(blocks))
+	}
+	for i, b := range blocks {
+		if want := uint64(i + 2); b.Height != want {
+			t.Errorf("blocks[%d].Height = %d, want %d", i, b.Height, want)
+		}
+	}
+
+	// Resume from the last block returned.
+	blocks, err = g.GetBlocks(ctx, blocks[len(blocks)-1].Height, 0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 1 || blocks[0].Height != 5 {
+		t.Errorf("got %d blocks, want only block 5", len(blocks))
+	}
+}
+
+func TestGetLatestBlocks(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	for i := 0; i < 4; i++ {
+		prottest.MakeBlock(t, c, nil)
+	}
+	g := New(c, nil, db)
+
+	blocks, err := g.GetLatestBlocks(ctx, 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 2 || blocks[0].Height != 5 || blocks[1].Height != 4 {
+		t.Errorf("got %d blocks, want blocks 5 and 4", len(blocks))
+	}
+}
+
+func TestGetBlockRange(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	for i := 0; i < 4; i++ {
+		prottest.MakeBlock(t, c, nil)
+	}
+	g := New(c, nil, db)
+
+	// The chain has blocks 1 through 5.
+	blocks, err := g.GetBlockRange(ctx, 2, 3)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 2 || blocks[0].Height != 2 || blocks[1].Height != 3 {
+		t.Errorf("got %d blocks, want blocks 2 and 3", len(blocks))
+	}
+
+	blocks, err = g.GetBlockRange(ctx, 5, 10)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 1 || blocks[0].Height != 5 {
+		t.Errorf("got %d blocks, want only block 5", len(blocks))
+	}
+}
+
+func TestGetBlockRangeBounds(t *testing.T) {
+	ctx := context.Background()
+	g := New(nil, nil, nil)
+	cases := []struct{ from, to uint64 }{
+		{3, 2},
+		{1, MaxBlockRange + 1},
+	}
+	for _, c := range cases {
+		_, err := g.GetBlockRange(ctx, c.from, c.to)
+		if errors.Root(err) != ErrBadBlockRange {
+			t.Errorf("GetBlockRange(%d, %d) = %v, want %v", c.from, c.to, err, ErrBadBlockRange)
+		}
+	}
+}
+
+func TestGetBlockByHash(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	want := prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, db)
+
+	got, err := g.GetBlockByHash(ctx, want.Hash())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if got.Hash() != want.Hash() {
+		t.Errorf("got block %d, want block %d", got.Height, want.Height)
+	}
+
+	_, err = g.GetBlockByHash(ctx, bc.Hash{})
+	if errors.Root(err) != ErrBlockNotFound {
+		t.Errorf("GetBlockByHash(unknown) = %v, want %v", err, ErrBlockNotFound)
+	}
+}
+
+func TestStreamBlocks(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	for i := 0; i < 4; i++ {
+		prottest.MakeBlock(t, c, nil)
+	}
+	g := New(c, nil, db)
+
+	var heights []uint64
+	err := g.StreamBlocks(ctx, 2, func(b *legacy.Block) error {
+		heights = append(heights, b.Height)
+		return nil
+	})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !reflect.DeepEqual(heights, []uint64{3, 4, 5}) {
+		t.Errorf("got heights %v, want [3 4 5]", heights)
+	}
+
+	// Returning an error from the callback stops the stream.
+	errStop := errors.New("stop")
+	heights = nil
+	err = g.StreamBlocks(ctx, 0, func(b *legacy.Block) error {
+		heights = append(heights, b.Height)
+		return errStop
+	})
+	if errors.Root(err) != errStop {
+		t.Errorf("got error %v, want %v", err, errStop)
+	}
+	if len(heights) != 1 {
+		t.Errorf("got %d blocks before stopping, want 1", len(heights))
+	}
+}
+
+func TestStreamBlocksBatched(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	for i := 0; i < 4; i++ {
+		prottest.MakeBlock(t, c, nil)
+	}
+	g := New(c, nil, db)
+	g.BlockFetchBatchSize = 2
+
+	blocks, err := g.GetBlocks(ctx, 0, 0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 5 || blocks[4].Height != 5 {
+		t.Errorf("got %d blocks in batches of 2, want 5", len(blocks))
+	}
+	blocks, err = g.GetBlocks(ctx, 1, 3)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 3 || blocks[0].Height != 2 || blocks[2].Height != 4 {
+		t.Errorf("got %d blocks with limit 3 in batches of 2, want blocks 2 through 4", len(blocks))
+	}
+}
+
+// BenchmarkStreamBlocks scans 100,000 blocks with and without
+// BlockFetchBatchSize.
+func BenchmarkStreamBlocks(b *testing.B) {
+	const n = 100000
+	ctx := context.Background()
+	_, db := pgtest.NewDB(b, pgtest.SchemaPath)
+	heights := make([]int64, 0, n)
+	hashes := make([][]byte, 0, n)
+	data := make([][]byte, 0, n)
+	for h := uint64(1); h <= n; h++ {
+		blk := &legacy.Block{BlockHeader: legacy.BlockHeader{Version: 1, Height: h}}
+		var buf bytes.Buffer
+		_, err := blk.WriteTo(&buf)
+		if err != nil {
+			b.Fatal(err)
+		}
+		hash := blk.Hash()
+		heights = append(heights, int64(h))
+		hashes = append(hashes, hash.Bytes())
+		data = append(data, buf.Bytes())
+	}
+	const q = `
+		INSERT INTO blocks (block_hash, height, data, header)
+		SELECT unnest($1::bytea[]), unnest($2::bigint[]), unnest($3::bytea[]), ''
+	`
+	_, err := db.ExecContext(ctx, q, pq.ByteaArray(hashes), pq.Int64Array(heights), pq.ByteaArray(data))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, batch := range []int{0, 1000, 10000} {
+		batch := batch
+		b.Run(fmt.Sprintf("batch%d", batch), func(b *testing.B) {
+			g := New(nil, nil, db)
+			g.BlockFetchBatchSize = batch
+			for i := 0; i < b.N; i++ {
+				var got int
+				err := g.StreamBlocks(ctx, 0, func(*legacy.Block) error {
+					got++
+					return nil
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if got != n {
+					b.Fatalf("got %d blocks, want %d", got, n)
+				}
+			}
+		})
+	}
+}
+
+func TestGetBlocksSinceTimeout(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+
+	blocks, err := g.GetBlocksSince(ctx, c.Height(), 10*time.Millisecond)
+	if err != ErrNoNewBlocks {
+		t.Fatalf("got error %v, want %v", err, ErrNoNewBlocks)
+	}
+	if blocks == nil || len(blocks) != 0 {
+		t.Errorf("got blocks %v, want an empty slice", blocks)
+	}
+}
+
+func TestWaitForHeight(t *testing.T) {
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := g.WaitForHeight(ctx, 2)
+	if errors.Root(err) != context.DeadlineExceeded {
+		t.Fatalf("WaitForHeight before block 2 = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	want := prottest.MakeBlock(t, c, nil)
+	got, err := g.WaitForHeight(context.Background(), 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if got.Hash() != want.Hash() {
+		t.Errorf("WaitForHeight(2) = %x, want %x", got.Hash().Bytes(), want.Hash().Bytes())
+	}
+}
+
+func TestMaxConcurrentBlockReads(t *testing.T) {
+	g := New(nil, nil, nil)
+	g.MaxConcurrentBlockReads = 1
+
+	release, err := g.startBlockRead(context.Background())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = g.startBlockRead(ctx)
+	if errors.Root(err) != ErrTooManyBlockReads {
+		t.Errorf("second startBlockRead = %v, want %v", err, ErrTooManyBlockReads)
+	}
+
+	release()
+	release, err = g.startBlockRead(context.Background())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	release()
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/groupcache/lru"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// DefaultIdempotencyKeyCacheSize is the number of idempotency keys
+// SubmitWithKey remembers if IdempotencyKeyCacheSize is zero.
+const DefaultIdempotencyKeyCacheSize = 10000
+
+type keyedTx struct {
+	id   bc.Hash
+	time time.Time
+}
+
+// SubmitWithKey is like SubmitTx, but collapses submissions that
+// share a client-chosen idempotency key, even if their txs differ.
+// The first tx accepted with a given key is remembered for
+// IdempotencyKeyTTL (if nonzero); until then, submitting with the
+// same key accepts nothing and returns the first tx's ID with
+// status Duplicate. Use TxStatus to find out what became of it.
+// A tx that's rejected doesn't use up its key.
+func (g *Generator) SubmitWithKey(ctx context.Context, key string, tx *legacy.Tx) (SubmitResult, error) {
+	g.keysMu.Lock()
+	defer g.keysMu.Unlock()
+
+	if g.keys == nil {
+		size := g.IdempotencyKeyCacheSize
+		if size <= 0 {
+			size = DefaultIdempotencyKeyCacheSize
+		}
+		g.keys = lru.New(size)
+	}
+	if v, ok := g.keys.Get(key); ok {
+		k := v.(keyedTx)
+		if g.IdempotencyKeyTTL <= 0 || g.since(k.time) <= g.IdempotencyKeyTTL {
+			return SubmitResult{ID: k.id, Status: Duplicate}, nil
+		}
+		g.keys.Remove(key)
+	}
+
+	res, err := g.SubmitTx(ctx, tx)
+	if err == nil {
+		g.keys.Add(key, keyedTx{id: tx.ID, time: g.now()})
+	}
+	return res, err
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/bc/bctest"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSubmitWithKey(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	tx1 := bctest.NewIssuanceTx(t, initial)
+	tx2 := bctest.NewIssuanceTx(t, initial)
+	tx3 := bctest.NewIssuanceTx(t, initial)
+
+	clock := &fakeClock{now: time.Now()}
+	g := New(c, nil, nil)
+	g.Clock = clock
+	g.IdempotencyKeyTTL = time.Minute
+
+	res, err := g.SubmitWithKey(ctx, "issue-1", tx1)
+	if err != nil || res.Status != Accepted {
+		t.Fatalf("first SubmitWithKey = %v, %v, want %v, <nil>", res.Status, err, Accepted)
+	}
+
+	// A different tx with the same key is collapsed into the first.
+	res, err = g.SubmitWithKey(ctx, "issue-1", tx2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if res.Status != Duplicate || res.ID != tx1.ID {
+		t.Errorf("resubmit with key = %v %x, want %v %x", res.Status, res.ID.Bytes(), Duplicate, tx1.ID.Bytes())
+	}
+	if n := len(g.PendingTxs()); n != 1 {
+		t.Errorf("got %d pending txs, want 1", n)
+	}
+
+	// Once the key expires, it can be used again.
+	clock.advance(2 * time.Minute)
+	res, err = g.SubmitWithKey(ctx, "issue-1", tx3)
+	if err != nil || res.Status != Accepted || res.ID != tx3.ID {
+		t.Errorf("SubmitWithKey after TTL = %v %x, %v, want %v %x, <nil>", res.Status, res.ID.Bytes(), err, Accepted, tx3.ID.Bytes())
+	}
+}
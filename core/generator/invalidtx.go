@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// invalidTx returns the index in b of the first transaction that
+// can't be applied to snapshot, the state before b, and why.
+// It returns -1 if every transaction applies.
+func invalidTx(b *legacy.Block, snapshot *state.Snapshot) (int, error) {
+	s := state.Copy(snapshot)
+	s.PruneNonces(b.TimestampMS)
+	for i, tx := range b.Transactions {
+		err := s.ApplyTx(tx.Tx)
+		if err != nil {
+			return i, err
+		}
+	}
+	return -1, nil
+}
+
+// applyPendingBlock returns the state after pending, the pending
+// block, applied to snapshot. If one of its transactions is
+// invalid, the error, with root ErrBadPendingBlock, has data items
+// transaction_id and reason identifying it.
+//
+// With DropInvalidTxOnCommit set, applyPendingBlock instead drops
+// the invalid transactions and returns a new block with the rest,
+// which replaces the pending block. No signer has signed the
+// pending block, because signers check blocks before signing.
+func (g *Generator) applyPendingBlock(ctx context.Context, pending, prev *legacy.Block, snapshot *state.Snapshot) (*legacy.Block, *state.Snapshot, error) {
+	s := state.Copy(snapshot)
+	applyErr := s.ApplyBlock(legacy.MapBlock(pending))
+	if applyErr == nil {
+		return pending, s, nil
+	}
+	i, txErr := invalidTx(pending, snapshot)
+	if i < 0 {
+		return nil, nil, errors.Sub(ErrBadPendingBlock, applyErr)
+	}
+	bad := pending.Transactions[i]
+	if !g.DropInvalidTxOnCommit || prev == nil {
+		err := errors.WithDetailf(errors.Sub(ErrBadPendingBlock, txErr), "transaction %x in block %d", bad.ID.Bytes(), pending.Height)
+		return nil, nil, errors.WithData(err, "transaction_id", bad.ID, "reason", txErr.Error())
+	}
+
+	txs := make([]*legacy.Tx, 0, len(pending.Transactions)-1)
+	txs = append(txs, pending.Transactions[:i]...)
+	txs = append(txs, pending.Transactions[i+1:]...)
+	b, s, err := g.generateBlock(ctx, prev, snapshot, pending.Time(), txs)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "generate")
+	}
+	for _, id := range droppedTxs(pending, b) {
+		reason := "not valid in the new block"
+		if id == bad.ID {
+			reason = txErr.Error()
+		}
+		log.Printkv(ctx, log.KeyMessage, "dropped invalid transaction from pending block",
+			"height", pending.Height, "tx", fmt.Sprintf("%x", id.Bytes()), "reason", reason)
+	}
+	err = g.replacePendingBlock(ctx, b)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "replacing pending block")
+	}
+	return b, s, nil
+}
+
+// droppedTxs returns the IDs of the transactions in old that
+// aren't in b.
+func droppedTxs(old, b *legacy.Block) []bc.Hash {
+	kept := make(map[bc.Hash]bool, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		kept[tx.ID] = true
+	}
+	var dropped []bc.Hash
+	for _, tx := range old.Transactions {
+		if !kept[tx.ID] {
+			dropped = append(dropped, tx.ID)
+		}
+	}
+	return dropped
+}
+
+// replacePendingBlock saves b as the pending block in place of
+// the pending block at the same height.
+func (g *Generator) replacePendingBlock(ctx context.Context, b *legacy.Block) error {
+	if g.db == nil {
+		g.pendingMu.Lock()
+		defer g.pendingMu.Unlock()
+		if g.pendingBlock == nil || g.pendingBlock.Height != b.Height {
+			return errors.Wrapf(errDuplicateBlock, "no pending block at height %d", b.Height)
+		}
+		g.pendingBlock = b
+		return nil
+	}
+
+	const q = `UPDATE generator_pending_block SET data = $1 WHERE height = $2`
+	res, err := g.db.ExecContext(ctx, q, b, b.Height)
+	if err != nil {
+		return errors.Wrap(err, "generator_pending_block update query")
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "generator_pending_block rows affected")
+	}
+	if affected == 0 {
+		return errors.Wrapf(errDuplicateBlock, "no pending block at height %d", b.Height)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// pendingBlockWithInvalidTx returns a chain and a block for its next
+// height with a valid tx and, after it, a tx already committed.
+func pendingBlockWithInvalidTx(t *testing.T) (c *protocol.Chain, pending, invalid *legacy.Tx, b *legacy.Block) {
+	ctx := context.Background()
+	c = prottest.NewChain(t)
+	initial := prottest.Initial(t, c)
+	invalid = bctest.NewIssuanceTx(t, initial.Hash())
+	prottest.MakeBlock(t, c, []*legacy.Tx{invalid})
+
+	pending = bctest.NewIssuanceTx(t, initial.Hash())
+	latest, s := c.State()
+	b, _, err := c.GenerateBlock(ctx, latest, s, time.Now(), []*legacy.Tx{pending})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	b.Transactions = append(b.Transactions, invalid)
+	return c, pending, invalid, b
+}
+
+func TestApplyPendingBlockInvalidTx(t *testing.T) {
+	ctx := context.Background()
+	c, _, invalid, b := pendingBlockWithInvalidTx(t)
+	latest, s := c.State()
+
+	g := New(c, nil, nil)
+	_, _, err := g.applyPendingBlock(ctx, b, latest, s)
+	if errors.Root(err) != ErrBadPendingBlock {
+		t.Fatalf("applyPendingBlock() = %v, want %v", err, ErrBadPendingBlock)
+	}
+	data := errors.Data(err)
+	if data["transaction_id"] != invalid.ID {
+		t.Errorf("transaction_id = %v, want %x", data["transaction_id"], invalid.ID.Bytes())
+	}
+	if reason, _ := data["reason"].(string); reason == "" {
+		t.Error("no reason for the invalid transaction")
+	}
+}
+
+func TestDropInvalidTxOnCommit(t *testing.T) {
+	ctx := context.Background()
+	dbtx := pgtest.NewTx(t)
+	c, pending, _, b := pendingBlockWithInvalidTx(t)
+	err := savePendingBlock(ctx, dbtx, b)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	g := New(c, nil, dbtx)
+	g.DropInvalidTxOnCommit = true
+	got, err := g.makeBlock(ctx, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(got.Transactions) != 1 || got.Transactions[0].ID != pending.ID {
+		t.Errorf("committed block has %d txs, want only tx %x", len(got.Transactions), pending.ID.Bytes())
+	}
+	if h := c.Height(); h != b.Height {
+		t.Errorf("chain height = %d, want %d", h, b.Height)
+	}
+	saved, err := getPendingBlock(ctx, dbtx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if saved.Hash() != got.Hash() {
+		t.Error("pending block wasn't replaced by the committed block")
+	}
+}
@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"chain/metrics"
+	"chain/protocol/bc/legacy"
+)
+
+// Block production metrics, published as expvars by generators
+// with EnableMetrics set.
+var (
+	publishOnce sync.Once
+	blocksMade  = new(expvar.Int)
+	blockErrors = new(expvar.Int)
+	chainHeight = new(expvar.Int)
+	pendingTxs  = new(expvar.Int)
+	expiredTxs  = new(expvar.Int)
+
+	droppedCallbacks   = new(expvar.Int)
+	droppedTxCallbacks = new(expvar.Int)
+	quorumMargin       = new(expvar.Int)
+	checkpointFailures = new(expvar.Int)
+
+	lastBlockNanos int64 // unix time of the latest block; accessed atomically
+
+	signerLatencyMu sync.Mutex
+	signerLatency   = make(map[string]*metrics.RotatingLatency)
+)
+
+func publishMetrics() {
+	publishOnce.Do(func() {
+		expvar.Publish("generator.blocks", blocksMade)
+		expvar.Publish("generator.block_errors", blockErrors)
+		expvar.Publish("generator.height", chainHeight)
+		expvar.Publish("generator.pending_txs", pendingTxs)
+		expvar.Publish("generator.expired_txs", expiredTxs)
+		expvar.Publish("generator.dropped_commit_callbacks", droppedCallbacks)
+		expvar.Publish("generator.dropped_tx_callbacks", droppedTxCallbacks)
+		expvar.Publish("generator.signer_quorum_margin", quorumMargin)
+		expvar.Publish("generator.checkpoint_failures", checkpointFailures)
+		expvar.Publish("generator.seconds_since_block", expvar.Func(func() interface{} {
+			t := atomic.LoadInt64(&lastBlockNanos)
+			if t == 0 {
+				return nil
+			}
+			return time.Since(time.Unix(0, t)).Seconds()
+		}))
+	})
+}
+
+// recordBlock records that b is the latest block.
+func (g *Generator) recordBlock(b *legacy.Block) {
+	if !g.EnableMetrics || b == nil {
+		return
+	}
+	publishMetrics()
+	chainHeight.Set(int64(b.Height))
+	atomic.StoreInt64(&lastBlockNanos, b.Time().UnixNano())
+}
+
+// recordBlockMade records the outcome of an attempt to make a block.
+func (g *Generator) recordBlockMade(err error) {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	if err != nil {
+		blockErrors.Add(1)
+	} else {
+		blocksMade.Add(1)
+	}
+}
+
+// recordPending records the size of the pending tx pool.
+// The caller must hold g.mu.
+func (g *Generator) recordPending() {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	pendingTxs.Set(int64(len(g.pool)))
+}
+
+// recordExpired records that n pending txs expired.
+func (g *Generator) recordExpired(n int) {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	expiredTxs.Add(int64(n))
+}
+
+// recordDroppedCallback records that OnBlockCommit was skipped
+// for a block because the callback queue was full.
+func (g *Generator) recordDroppedCallback() {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	droppedCallbacks.Add(1)
+}
+
+// recordDroppedTxCallback records that OnTxAccepted was skipped
+// for a tx because the callback queue was full.
+func (g *Generator) recordDroppedTxCallback() {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	droppedTxCallbacks.Add(1)
+}
+
+// recordQuorumMargin records the number of reachable block
+// signers beyond the quorum.
+func (g *Generator) recordQuorumMargin(n int) {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	quorumMargin.Set(int64(n))
+}
+
+// recordCheckpointFailure records that saving a snapshot
+// checkpoint failed.
+func (g *Generator) recordCheckpointFailure() {
+	if !g.EnableMetrics {
+		return
+	}
+	publishMetrics()
+	checkpointFailures.Add(1)
+}
+
+// recordSignerLatency records d, how long a signing request to
+// signer took, in a latency histogram specific to that signer.
+func (g *Generator) recordSignerLatency(signer BlockSigner, d time.Duration) {
+	if !g.EnableMetrics {
+		return
+	}
+	key := fmt.Sprint(signer)
+	signerLatencyMu.Lock()
+	l := signerLatency[key]
+	if l == nil {
+		l = metrics.NewRotatingLatency(5, 5*time.Second)
+		signerLatency[key] = l
+		metrics.PublishLatency("generator.sign_block "+key, l)
+	}
+	signerLatencyMu.Unlock()
+	l.Record(d)
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"chain/database/pg"
+	"chain/errors"
+	"chain/protocol"
+)
+
+// ErrObserver is returned by the block production methods of a
+// generator made with NewObserver.
+var ErrObserver = errors.New("generator is an observer and doesn't produce blocks")
+
+// NewObserver returns a Generator that serves blocks and answers
+// queries about the blockchain, with GetBlocks, GetBlockByHash,
+// TxStatus and the like, but never produces blocks: Generate,
+// MakeBlock, AssembleBlock and Bootstrap return ErrObserver. It
+// has no block signers.
+//
+// An observer is for processes that aren't meant to become leader,
+// so they can't end up generating blocks alongside the real leader.
+// It has no use for pending txs, so Submit, SubmitTx, SubmitBatch
+// and the methods built on them return ErrObserver too; callers
+// should send txs to the leader instead.
+func NewObserver(c *protocol.Chain, db pg.DB) *Generator {
+	g := New(c, nil, db)
+	g.observer = true
+	return g
+}
+
+// IsObserver reports whether g was made with NewObserver.
+func (g *Generator) IsObserver() bool {
+	return g.observer
+}
+
+// checkProducer returns ErrObserver if g is an observer.
+func (g *Generator) checkProducer() error {
+	if g.observer {
+		return ErrObserver
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+)
+
+func TestObserver(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	b2 := prottest.MakeBlock(t, c, nil)
+	g := NewObserver(c, nil)
+	if !g.IsObserver() {
+		t.Fatal("IsObserver() = false, want true")
+	}
+
+	if _, err := g.MakeBlock(ctx); err != ErrObserver {
+		t.Errorf("MakeBlock() error = %v, want %v", err, ErrObserver)
+	}
+	if _, _, err := g.AssembleBlock(ctx); err != ErrObserver {
+		t.Errorf("AssembleBlock() error = %v, want %v", err, ErrObserver)
+	}
+	if err := g.Bootstrap(ctx, b2); err != ErrObserver {
+		t.Errorf("Bootstrap() error = %v, want %v", err, ErrObserver)
+	}
+	var healthErr error
+	err := g.Generate(ctx, time.Second, func(err error) { healthErr = err })
+	if err != ErrObserver || healthErr != ErrObserver {
+		t.Errorf("Generate() = %v, health(%v), want %v", err, healthErr, ErrObserver)
+	}
+
+	tx := legacy.NewTx(legacy.TxData{Version: 1})
+	if err := g.Submit(ctx, tx); err != ErrObserver {
+		t.Errorf("Submit() error = %v, want %v", err, ErrObserver)
+	}
+	if res, err := g.SubmitTx(ctx, tx); err != ErrObserver || res.Status != Rejected {
+		t.Errorf("SubmitTx() = %v, %v, want %v, %v", res.Status, err, Rejected, ErrObserver)
+	}
+	if _, err := g.SubmitBatch(ctx, []*legacy.Tx{tx}); err != ErrObserver {
+		t.Errorf("SubmitBatch() error = %v, want %v", err, ErrObserver)
+	}
+	if _, err := g.SubmitWithKey(ctx, "key", tx); err != ErrObserver {
+		t.Errorf("SubmitWithKey() error = %v, want %v", err, ErrObserver)
+	}
+	if n := len(g.PendingTxs()); n != 0 {
+		t.Errorf("observer has %d pending txs, want 0", n)
+	}
+
+	if got := g.LatestBlock(); got.Hash() != b2.Hash() {
+		t.Errorf("LatestBlock() = block %d, want block 2", got.Height)
+	}
+	if h := c.Height(); h != 2 {
+		t.Errorf("chain height = %d, want 2", h)
+	}
+}
@@ -0,0 +1,84 @@
+package generator
+
+import (
+	"time"
+
+	"chain/database/pg"
+	"chain/protocol"
+)
+
+// An Option configures a Generator made with NewWithOptions.
+//
+// Options cover the settings most callers need. Any exported field
+// of Generator can still be set directly after construction, before
+// the generator is used.
+type Option func(*Generator)
+
+// NewWithOptions is like New, but takes its block signers and
+// other settings as opts; settings without an option keep their
+// zero-value defaults, as with New. There's no option for the
+// quorum: that's part of each block's consensus program, set by
+// protocol.NewInitialBlock.
+func NewWithOptions(c *protocol.Chain, db pg.DB, opts ...Option) *Generator {
+	g := New(c, nil, db)
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// WithSigners sets the generator's block signers.
+func WithSigners(s ...BlockSigner) Option {
+	return func(g *Generator) {
+		s = append([]BlockSigner(nil), s...)
+		g.signers = s
+		g.breakers = newBreakers(len(s))
+		g.stats = newSignerStats(len(s))
+	}
+}
+
+// WithPeriod sets the block period Generate uses when it's
+// called with a zero period. A period that isn't positive is
+// ignored.
+func WithPeriod(d time.Duration) Option {
+	return func(g *Generator) {
+		if d > 0 {
+			g.period = d
+		}
+	}
+}
+
+// WithMaxTxPerBlock sets MaxTxPerBlock.
+func WithMaxTxPerBlock(n int) Option {
+	return func(g *Generator) { g.MaxTxPerBlock = n }
+}
+
+// WithMaxTxBytes sets MaxTxBytes.
+func WithMaxTxBytes(n int) Option {
+	return func(g *Generator) { g.MaxTxBytes = n }
+}
+
+// WithMaxPendingTxs sets MaxPendingTxs.
+func WithMaxPendingTxs(n int) Option {
+	return func(g *Generator) { g.MaxPendingTxs = n }
+}
+
+// WithSigningTimeout sets SigningTimeout.
+func WithSigningTimeout(d time.Duration) Option {
+	return func(g *Generator) { g.SigningTimeout = d }
+}
+
+// WithTxSource sets TxSource.
+func WithTxSource(s TxSource) Option {
+	return func(g *Generator) { g.TxSource = s }
+}
+
+// WithClock sets Clock.
+func WithClock(c Clock) Option {
+	return func(g *Generator) { g.Clock = c }
+}
+
+// WithMetrics sets EnableMetrics.
+func WithMetrics() Option {
+	return func(g *Generator) { g.EnableMetrics = true }
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/prottest"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 2))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	signers := []BlockSigner{
+		testSigner{nil, pubkeys[0], privkeys[0]},
+		testSigner{nil, pubkeys[1], privkeys[1]},
+	}
+	g := NewWithOptions(c, nil,
+		WithSigners(signers...),
+		WithPeriod(time.Minute),
+		WithMaxTxPerBlock(10),
+		WithSigningTimeout(time.Second),
+	)
+
+	if n := len(g.SignerStats()); n != 2 {
+		t.Errorf("got %d signers, want 2", n)
+	}
+	signers[0] = nil
+	if g.signers[0] == nil {
+		t.Error("WithSigners kept the caller's slice")
+	}
+	if p := g.Period(); p != time.Minute {
+		t.Errorf("Period() = %s, want 1m0s", p)
+	}
+	if g.MaxTxPerBlock != 10 || g.SigningTimeout != time.Second {
+		t.Errorf("MaxTxPerBlock, SigningTimeout = %d, %s, want 10, 1s", g.MaxTxPerBlock, g.SigningTimeout)
+	}
+
+	g = NewWithOptions(c, nil, WithPeriod(-time.Second))
+	if p := g.Period(); p != 0 {
+		t.Errorf("Period() after WithPeriod(-1s) = %s, want 0", p)
+	}
+	err := g.Generate(context.Background(), 0, func(error) {})
+	if err != ErrBadPeriod {
+		t.Errorf("Generate(0) with no period = %v, want %v", err, ErrBadPeriod)
+	}
+}
@@ -0,0 +1,94 @@
+package generator
+
+import (
+	"bytes"
+	"container/heap"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// A TxOrdering determines the order of transactions within
+// the blocks the generator makes.
+type TxOrdering int
+
+const (
+	// OrderByArrival puts transactions in the order they were
+	// submitted. It is the default.
+	OrderByArrival TxOrdering = iota
+
+	// OrderByHash sorts transactions by ID, except that a
+	// transaction always comes after any transaction in the same
+	// block whose outputs it spends. Given the same set of
+	// transactions, it always produces the same order.
+	OrderByHash
+)
+
+// orderTxs returns txs, which must be in topological order,
+// in the order given by o.
+func orderTxs(txs []*legacy.Tx, o TxOrdering) []*legacy.Tx {
+	if o != OrderByHash || len(txs) < 2 {
+		return txs
+	}
+
+	producer := make(map[bc.Hash]int)
+	for i, tx := range txs {
+		for _, id := range tx.ResultIds {
+			producer[*id] = i
+		}
+	}
+	ndeps := make([]int, len(txs))
+	dependents := make([][]int, len(txs))
+	for i, tx := range txs {
+		for _, id := range tx.SpentOutputIDs {
+			if j, ok := producer[id]; ok && j != i {
+				ndeps[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+
+	ready := &txHeap{txs: txs}
+	for i := range txs {
+		if ndeps[i] == 0 {
+			ready.idx = append(ready.idx, i)
+		}
+	}
+	heap.Init(ready)
+
+	ordered := make([]*legacy.Tx, 0, len(txs))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		ordered = append(ordered, txs[i])
+		for _, d := range dependents[i] {
+			ndeps[d]--
+			if ndeps[d] == 0 {
+				heap.Push(ready, d)
+			}
+		}
+	}
+	return ordered
+}
+
+// txHeap is a heap of indexes into txs, ordered by tx ID.
+type txHeap struct {
+	txs []*legacy.Tx
+	idx []int
+}
+
+func (h *txHeap) Len() int      { return len(h.idx) }
+func (h *txHeap) Swap(i, j int) { h.idx[i], h.idx[j] = h.idx[j], h.idx[i] }
+
+func (h *txHeap) Less(i, j int) bool {
+	a, b := h.txs[h.idx[i]].ID.Byte32(), h.txs[h.idx[j]].ID.Byte32()
+	return bytes.Compare(a[:], b[:]) < 0
+}
+
+func (h *txHeap) Push(x interface{}) { h.idx = append(h.idx, x.(int)) }
+
+func (h *txHeap) Pop() interface{} {
+	n := len(h.idx)
+	x := h.idx[n-1]
+	h.idx = h.idx[:n-1]
+	return x
+}
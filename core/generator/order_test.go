@@ -0,0 +1,45 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+func TestOrderTxs(t *testing.T) {
+	// Tx IDs sort as a < b < c < d, but c spends an output of d.
+	out := bc.NewHash([32]byte{0xff})
+	a := testTx(1, nil, nil)
+	b := testTx(2, nil, nil)
+	c := testTx(3, nil, []bc.Hash{out})
+	d := testTx(4, []*bc.Hash{&out}, nil)
+	txs := []*legacy.Tx{d, b, c, a}
+
+	got := orderTxs(txs, OrderByArrival)
+	if !reflect.DeepEqual(got, txs) {
+		t.Errorf("OrderByArrival changed the order of txs")
+	}
+
+	got = orderTxs(txs, OrderByHash)
+	want := []*legacy.Tx{a, b, d, c}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderByHash got %v, want %v", txIDs(got), txIDs(want))
+	}
+}
+
+func testTx(id byte, results []*bc.Hash, spends []bc.Hash) *legacy.Tx {
+	return &legacy.Tx{Tx: &bc.Tx{
+		TxHeader:       &bc.TxHeader{ResultIds: results},
+		ID:             bc.NewHash([32]byte{id}),
+		SpentOutputIDs: spends,
+	}}
+}
+
+func txIDs(txs []*legacy.Tx) (ids []byte) {
+	for _, tx := range txs {
+		ids = append(ids, tx.ID.Bytes()[0])
+	}
+	return ids
+}
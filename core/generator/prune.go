@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// PruneMempool removes from the pending tx pool any tx that's in a
+// committed block, such as one committed by another process, and
+// returns the number removed. The generator does this itself for
+// the blocks it commits.
+//
+// It only looks at blocks committed since the pool was last empty
+// or pruned, because a tx committed before it was submitted is
+// invalid and is dropped when it's taken for a block anyway. It
+// doesn't apply to a TxSource.
+func (g *Generator) PruneMempool(ctx context.Context) (int, error) {
+	if g.TxSource != nil {
+		return 0, nil
+	}
+	latest, _ := g.latest()
+	if latest == nil {
+		return 0, nil
+	}
+
+	g.mu.Lock()
+	from := g.prunedHeight + 1
+	g.mu.Unlock()
+
+	var pruned int
+	for h := from; h <= latest.Height; h++ {
+		b, err := g.chain.GetBlock(ctx, h)
+		if err != nil {
+			return pruned, errors.Wrapf(err, "getting block at height %d", h)
+		}
+		pruned += g.pruneCommitted(b)
+	}
+	return pruned, nil
+}
+
+// pruneCommitted removes the txs in b, a committed block, from the
+// pending tx pool, and returns the number removed.
+func (g *Generator) pruneCommitted(b *legacy.Block) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if b.Height > g.prunedHeight {
+		g.prunedHeight = b.Height
+	}
+	if len(g.pool) == 0 {
+		return 0
+	}
+
+	committed := make(map[bc.Hash]bool, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		if _, ok := g.poolTimes[tx.ID]; ok {
+			committed[tx.ID] = true
+		}
+	}
+	if len(committed) == 0 {
+		return 0
+	}
+	keep := make([]*legacy.Tx, 0, len(g.pool)-len(committed))
+	for _, tx := range g.pool {
+		if committed[tx.ID] {
+			delete(g.poolTimes, tx.ID)
+		} else {
+			keep = append(keep, tx)
+		}
+	}
+	g.pool = keep
+	if len(keep) == 0 {
+		g.poolTimes = make(map[bc.Hash]time.Time)
+	}
+	g.recordPending()
+	return len(committed)
+}
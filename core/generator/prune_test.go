@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestPruneMempool(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	g := New(c, nil, nil)
+
+	committed, pending := bctest.NewIssuanceTx(t, initial), bctest.NewIssuanceTx(t, initial)
+	for _, tx := range []*legacy.Tx{committed, pending} {
+		err := g.Submit(ctx, tx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	// Another process commits one of the txs.
+	prev, s := c.State()
+	b, s, err := c.GenerateBlock(ctx, prev, s, time.Now(), []*legacy.Tx{committed})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.CommitAppliedBlock(ctx, b, s)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	n, err := g.PruneMempool(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if n != 1 {
+		t.Errorf("PruneMempool() = %d, want 1", n)
+	}
+	if txs := g.PendingTxs(); len(txs) != 1 || txs[0].ID != pending.ID {
+		t.Errorf("pending txs after pruning = %v, want only the uncommitted tx", txs)
+	}
+
+	n, err = g.PruneMempool(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if n != 0 {
+		t.Errorf("PruneMempool() again = %d, want 0", n)
+	}
+}
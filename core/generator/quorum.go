@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+
+	"chain/log"
+)
+
+// reachableSigners returns the number of signers in stats whose
+// most recent signing request, if any, got an answer: a signature
+// or a refusal by policy.
+func reachableSigners(stats []*signerStats) int {
+	n := 0
+	for _, s := range stats {
+		s.mu.Lock()
+		if !s.unreachable {
+			n++
+		}
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// checkQuorumMargin records how many more signers are reachable
+// than quorum requires, and logs a warning when that margin
+// falls to zero or below: at zero, losing one more signer halts
+// the blockchain.
+func (g *Generator) checkQuorumMargin(ctx context.Context, stats []*signerStats, quorum int) {
+	margin := reachableSigners(stats) - quorum
+	g.recordQuorumMargin(margin)
+	if margin > 0 {
+		atomic.StoreInt32(&g.quorumWarned, 0)
+		return
+	}
+	if atomic.SwapInt32(&g.quorumWarned, 1) == 0 {
+		log.Printkv(ctx, log.KeyMessage, "block signer quorum margin is exhausted; losing another signer will halt the blockchain",
+			"reachable", margin+quorum, "quorum", quorum)
+	}
+}
@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/log"
+)
+
+func TestCheckQuorumMargin(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	ctx := context.Background()
+	g := New(nil, nil, nil)
+	g.EnableMetrics = true
+	stats := newSignerStats(3)
+	now := time.Now()
+	stats[0].record(now, 0, nil, false)
+	stats[1].record(now, 0, errors.Wrap(ErrPolicyRejected), false) // refused, but reachable
+	stats[2].record(now, 0, errors.New("signer unavailable"), false)
+
+	g.checkQuorumMargin(ctx, stats, 1)
+	if got := quorumMargin.Value(); got != 1 {
+		t.Errorf("quorum margin = %d, want 1", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got log output %q with a margin left, want none", buf.String())
+	}
+
+	for i := 0; i < 2; i++ {
+		g.checkQuorumMargin(ctx, stats, 2)
+	}
+	if got := quorumMargin.Value(); got != 0 {
+		t.Errorf("quorum margin = %d, want 0", got)
+	}
+	if n := strings.Count(buf.String(), "quorum margin is exhausted"); n != 1 {
+		t.Errorf("got %d quorum margin warnings, want 1", n)
+	}
+}
@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"time"
+
+	"github.com/golang/groupcache/lru"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// recentlySubmitted reports whether the tx with the given ID was
+// accepted within the last RecentTxTTL, according to the cache of
+// recently submitted txs.
+func (g *Generator) recentlySubmitted(id bc.Hash) bool {
+	if g.RecentTxCacheSize <= 0 {
+		return false
+	}
+	g.recentMu.Lock()
+	defer g.recentMu.Unlock()
+	if g.recent == nil {
+		return false
+	}
+	v, ok := g.recent.Get(id)
+	if !ok {
+		return false
+	}
+	if g.RecentTxTTL > 0 && g.since(v.(time.Time)) > g.RecentTxTTL {
+		g.recent.Remove(id)
+		return false
+	}
+	return true
+}
+
+// noteSubmitted adds the tx with the given ID to the cache of
+// recently submitted txs.
+func (g *Generator) noteSubmitted(id bc.Hash) {
+	if g.RecentTxCacheSize <= 0 {
+		return
+	}
+	g.recentMu.Lock()
+	defer g.recentMu.Unlock()
+	if g.recent == nil {
+		g.recent = lru.New(g.RecentTxCacheSize)
+	}
+	g.recent.Add(id, g.now())
+}
+
+// forgetSubmitted removes txs from the cache of recently
+// submitted txs.
+func (g *Generator) forgetSubmitted(txs []*legacy.Tx) {
+	if g.RecentTxCacheSize <= 0 {
+		return
+	}
+	g.recentMu.Lock()
+	defer g.recentMu.Unlock()
+	if g.recent == nil {
+		return
+	}
+	for _, tx := range txs {
+		g.recent.Remove(tx.ID)
+	}
+}
@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+var (
+	// ErrUnrecognizedChain is returned by Bootstrap when the given
+	// initial block doesn't belong to the generator's blockchain.
+	ErrUnrecognizedChain = errors.New("initial block does not match blockchain")
+
+	// ErrNotBootstrapped is returned when making a block on a
+	// blockchain that has no initial block yet.
+	ErrNotBootstrapped = errors.New("blockchain has no initial block")
+
+	// ErrPendingBlockGap is returned by Generate, and passed to its
+	// health callback, when the pending block left by a previous
+	// leader is more than one block ahead of the blockchain, which
+	// suggests this process is missing committed blocks. Generate
+	// can't make blocks until the gap is resolved, since the
+	// pending block would never be replaced.
+	ErrPendingBlockGap = errors.New("pending block is ahead of the blockchain")
+
+	// ErrRecoveredTip is returned by Generate when the latest block
+	// doesn't agree with the committed blocks before it; see
+	// Generator.RecoveryVerifyDepth.
+	ErrRecoveredTip = errors.New("latest block does not chain to committed blocks")
+)
+
+// Bootstrap commits b as the initial block of an empty blockchain.
+// It's a no-op if the blockchain already has an initial block, and
+// returns ErrUnrecognizedChain if b isn't the initial block the
+// blockchain was created with.
+//
+// Initial blocks come from protocol.NewInitialBlock, which sets the
+// consensus program (signer keys and quorum) for the next block.
+func (g *Generator) Bootstrap(ctx context.Context, b *legacy.Block) error {
+	if err := g.checkProducer(); err != nil {
+		return err
+	}
+	if b.Hash() != g.chain.InitialBlockHash {
+		return errors.WithDetailf(ErrUnrecognizedChain, "block %x, blockchain ID %x", b.Hash().Bytes(), g.chain.InitialBlockHash.Bytes())
+	}
+
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+
+	if g.LatestBlock() != nil {
+		return nil
+	}
+	err := g.chain.ValidateBlock(b, nil)
+	if err != nil {
+		return errors.Wrap(err, "validating initial block")
+	}
+	err = g.chain.CommitAppliedBlock(ctx, b, state.Empty())
+	return errors.Wrap(err, "committing initial block")
+}
+
+// A Recovery describes what happened to a block that a previous
+// leader generated but didn't commit, as found by Generate when it
+// started.
+type Recovery struct {
+	Time      time.Time     // when the pending block was found
+	Height    uint64        // height of the pending block
+	Hash      bc.Hash       // hash of the pending block
+	Pending   time.Duration // how long ago the block was generated
+	Committed bool          // whether the block was committed
+	Stale     bool          // whether a block at its height was already committed
+	Err       error         // why the block wasn't committed, if known
+}
+
+// LastRecovery returns the outcome of the most recent recovery of
+// a pending block, or nil if Generate hasn't found one.
+func (g *Generator) LastRecovery() *Recovery {
+	g.recoveryMu.Lock()
+	defer g.recoveryMu.Unlock()
+	return g.lastRecovery
+}
+
+// recoverPendingBlock commits a pending block left by a previous
+// leader, if there is one, and records the outcome for LastRecovery.
+// A pending block at a height that's already committed is stale and
+// is left alone; one that's more than a block ahead of the
+// blockchain gets ErrPendingBlockGap.
+func (g *Generator) recoverPendingBlock(ctx context.Context) error {
+	b, err := g.loadPendingBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "retrieving the pending block")
+	}
+	if b == nil {
+		return nil
+	}
+
+	rec := &Recovery{
+		Time:    g.now(),
+		Height:  b.Height,
+		Hash:    b.Hash(),
+		Pending: g.since(b.Time()),
+	}
+	var latestHeight uint64
+	if latest, _ := g.latest(); latest != nil {
+		latestHeight = latest.Height
+	}
+	switch {
+	case latestHeight > 0 && b.Height <= latestHeight:
+		rec.Stale = true
+	case latestHeight > 0 && b.Height > latestHeight+1:
+		err = errors.WithDetailf(ErrPendingBlockGap, "pending block height %d, blockchain height %d", b.Height, latestHeight)
+	default:
+		var committed *legacy.Block
+		committed, err = g.makeBlock(ctx, false)
+		rec.Committed = err == nil && committed != nil && committed.Hash() == rec.Hash
+	}
+	rec.Err = err
+
+	log.Printkv(ctx,
+		log.KeyMessage, "recovered pending block",
+		"height", rec.Height,
+		"block_hash", fmt.Sprintf("%x", rec.Hash.Bytes()),
+		"pending", rec.Pending,
+		"committed", rec.Committed,
+		"stale", rec.Stale,
+		"blockchain_height", latestHeight,
+	)
+	g.recoveryMu.Lock()
+	g.lastRecovery = rec
+	g.recoveryMu.Unlock()
+	return err
+}
+
+// verifyRecentBlocks checks that latest is the committed block at
+// its height and that it and the g.RecoveryVerifyDepth-1 blocks
+// before it each link to their predecessor, returning
+// ErrRecoveredTip if not.
+func (g *Generator) verifyRecentBlocks(ctx context.Context, latest *legacy.Block) error {
+	if g.RecoveryVerifyDepth == 0 || latest == nil {
+		return nil
+	}
+	stored, err := g.chain.GetBlock(ctx, latest.Height)
+	if err != nil {
+		return errors.Wrapf(err, "getting block at height %d", latest.Height)
+	}
+	if stored.Hash() != latest.Hash() {
+		return errors.WithDetailf(ErrRecoveredTip, "latest block %x, committed block at height %d %x", latest.Hash().Bytes(), latest.Height, stored.Hash().Bytes())
+	}
+
+	b := latest
+	for i := uint64(1); i < g.RecoveryVerifyDepth && b.Height > 1; i++ {
+		prev, err := g.chain.GetBlock(ctx, b.Height-1)
+		if err != nil {
+			return errors.Wrapf(err, "getting block at height %d", b.Height-1)
+		}
+		if b.PreviousBlockHash != prev.Hash() {
+			return errors.WithDetailf(ErrRecoveredTip, "block %d has previous block hash %x, committed block at height %d is %x", b.Height, b.PreviousBlockHash.Bytes(), prev.Height, prev.Hash().Bytes())
+		}
+		b = prev
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/prottest/memstore"
+	"chain/testutil"
+)
+
+func TestBootstrap(t *testing.T) {
+	ctx := context.Background()
+	b1, err := protocol.NewInitialBlock(nil, 0, time.Now())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	c, err := protocol.NewChain(ctx, b1.Hash(), memstore.New(), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	g := New(c, nil, nil)
+
+	other, err := protocol.NewInitialBlock(nil, 0, time.Now().Add(time.Second))
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.Bootstrap(ctx, other)
+	if errors.Root(err) != ErrUnrecognizedChain {
+		t.Errorf("Bootstrap(other) = %v, want %v", err, ErrUnrecognizedChain)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = g.Bootstrap(ctx, b1)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if got := g.LatestBlock(); got == nil || got.Hash() != b1.Hash() {
+			t.Fatalf("after Bootstrap, latest block = %v, want initial block", got)
+		}
+	}
+}
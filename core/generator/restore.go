@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"chain/database/pg"
+	"chain/errors"
+	"chain/log"
+	"chain/protocol"
+	"chain/protocol/bc/legacy"
+)
+
+var (
+	// ErrGenerating is returned by RestoreSnapshot while Generate
+	// is running and not paused.
+	ErrGenerating = errors.New("generator is making blocks")
+
+	// ErrNoSnapshot is returned by RestoreSnapshot when there is
+	// no saved snapshot at the requested height.
+	ErrNoSnapshot = errors.New("no snapshot at that height")
+)
+
+// RestoreSnapshot rebuilds the state the generator builds on from
+// the snapshot saved at the given height, replaying the committed
+// blocks after it, for recovery from a corrupt working state. The
+// generator then builds on the latest committed block with the
+// rebuilt state, as if by SetTip. The blockchain's own state is
+// unchanged; restart the process to reload it.
+//
+// The height must be one the blockchain saved a snapshot at, on
+// its own schedule or because of SnapshotInterval. The snapshot
+// must match the state root of the block at that height, and each
+// replayed block's state root must match the state after it, or
+// RestoreSnapshot returns protocol.ErrBadStateRoot and changes
+// nothing; an older snapshot may then be tried.
+//
+// RestoreSnapshot refuses to run, returning ErrGenerating, while
+// Generate is running, unless it's paused. An operator should
+// Pause the generator (or stop it), call RestoreSnapshot, check
+// Health, and then Resume.
+func (g *Generator) RestoreSnapshot(ctx context.Context, height uint64) error {
+	if err := g.checkProducer(); err != nil {
+		return err
+	}
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	if atomic.LoadInt32(&g.running) != 0 && !g.IsPaused() {
+		return ErrGenerating
+	}
+	if err := g.checkDB(); err != nil {
+		return err
+	}
+
+	s, err := g.store.GetStateSnapshot(ctx, height)
+	if errors.Root(err) == pg.ErrUserInputNotFound {
+		return errors.WithDetailf(ErrNoSnapshot, "height %d", height)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "getting snapshot at height %d", height)
+	}
+
+	b, err := g.chain.GetBlock(ctx, height)
+	if err != nil {
+		return errors.Wrapf(err, "getting block at height %d", height)
+	}
+	if b.AssetsMerkleRoot != s.Tree.RootHash() {
+		return errors.WithDetailf(protocol.ErrBadStateRoot, "snapshot at height %d", height)
+	}
+
+	var latest uint64
+	if l, _ := g.chain.State(); l != nil {
+		latest = l.Height
+	}
+	for h := height + 1; h <= latest; h++ {
+		b, err = g.chain.GetBlock(ctx, h)
+		if err != nil {
+			return errors.Wrapf(err, "getting block at height %d", h)
+		}
+		err = s.ApplyBlock(legacy.MapBlock(b))
+		if err != nil {
+			return errors.Wrapf(err, "applying block %d", h)
+		}
+		if b.AssetsMerkleRoot != s.Tree.RootHash() {
+			return errors.WithDetailf(protocol.ErrBadStateRoot, "block %d", h)
+		}
+	}
+
+	g.tipMu.Lock()
+	g.tipBlock, g.tipSnapshot = b, s
+	g.tipMu.Unlock()
+	log.Printkv(ctx, log.KeyMessage, "restored state snapshot",
+		"snapshot_height", height,
+		"height", b.Height,
+		"block_hash", fmt.Sprintf("%x", b.Hash().Bytes()),
+	)
+	return nil
+}
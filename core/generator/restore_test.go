@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"chain/core/txdb"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	initial := prottest.Initial(t, c)
+	prottest.MakeBlock(t, c, []*legacy.Tx{bctest.NewIssuanceTx(t, initial.Hash())})
+	_, s := c.State()
+	err := c.SaveSnapshot(ctx, 2, s)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	b3 := prottest.MakeBlock(t, c, []*legacy.Tx{bctest.NewIssuanceTx(t, initial.Hash())})
+	g := New(c, nil, db)
+
+	err = g.RestoreSnapshot(ctx, 1)
+	if errors.Root(err) != ErrNoSnapshot {
+		t.Errorf("RestoreSnapshot(1) error = %v, want %v", err, ErrNoSnapshot)
+	}
+
+	err = g.RestoreSnapshot(ctx, 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	tip, snapshot := g.latest()
+	if tip.Hash() != b3.Hash() {
+		t.Errorf("tip after RestoreSnapshot = block %d, want %d", tip.Height, b3.Height)
+	}
+	if snapshot.Tree.RootHash() != b3.AssetsMerkleRoot {
+		t.Errorf("restored state root = %x, want %x", snapshot.Tree.RootHash().Bytes(), b3.AssetsMerkleRoot.Bytes())
+	}
+}
+
+func TestRestoreSnapshotRunning(t *testing.T) {
+	ctx := context.Background()
+	g := New(nil, nil, nil)
+	atomic.StoreInt32(&g.running, 1)
+	err := g.RestoreSnapshot(ctx, 1)
+	if err != ErrGenerating {
+		t.Errorf("RestoreSnapshot while generating error = %v, want %v", err, ErrGenerating)
+	}
+}
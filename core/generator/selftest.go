@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"context"
+	"crypto/rand"
+	"time"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+	"chain/protocol/vm"
+	"chain/protocol/vm/vmutil"
+)
+
+// ErrSelfTest is returned by SelfTest when a block it made doesn't
+// hold the transaction it submitted for that block.
+var ErrSelfTest = errors.New("self-test block is missing its transaction")
+
+// SelfTest makes n blocks on a new, throwaway blockchain kept in
+// store, each with one transaction and signed by an in-process
+// block signer, then checks that every block is signed by a quorum
+// of its predecessor's consensus keys and chains to it. It returns
+// the first failure. It's meant for CI tests of the generator's
+// assemble, sign, commit and verify loop; it doesn't contact a
+// core's configured block signers, so it says nothing about them.
+//
+// The store must be empty and not used by any other blockchain.
+// The generator under test has no database and keeps its pending
+// block in memory.
+func SelfTest(ctx context.Context, store protocol.Store, n int) error {
+	pubkey, privkey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return errors.Wrap(err, "generating block signing key")
+	}
+	initial, err := protocol.NewInitialBlock([]ed25519.PublicKey{pubkey}, 1, time.Now())
+	if err != nil {
+		return errors.Wrap(err, "making initial block")
+	}
+	c, err := protocol.NewChain(ctx, initial.Hash(), store, nil)
+	if err != nil {
+		return errors.Wrap(err, "making blockchain")
+	}
+	err = c.CommitAppliedBlock(ctx, initial, state.Empty())
+	if err != nil {
+		return errors.Wrap(err, "committing initial block")
+	}
+
+	g := New(c, []BlockSigner{selfTestSigner{privkey}}, nil)
+	for i := 0; i < n; i++ {
+		tx, err := selfTestTx(initial.Hash())
+		if err != nil {
+			return err
+		}
+		err = g.Submit(ctx, tx)
+		if err != nil {
+			return errors.Wrapf(err, "submitting tx %d", i)
+		}
+		b, err := g.MakeBlock(ctx)
+		if err != nil {
+			return errors.Wrapf(err, "making block %d", i)
+		}
+		if len(b.Transactions) != 1 || b.Transactions[0].ID != tx.ID {
+			return errors.WithDetailf(ErrSelfTest, "block %d", b.Height)
+		}
+	}
+
+	prev := initial
+	for h := initial.Height + 1; h <= c.Height(); h++ {
+		b, err := c.GetBlock(ctx, h)
+		if err != nil {
+			return errors.Wrapf(err, "getting block %d", h)
+		}
+		if b.PreviousBlockHash != prev.Hash() {
+			return errors.WithDetailf(ErrNotNextBlock, "block %d has previous block hash %x, want %x", h, b.PreviousBlockHash.Bytes(), prev.Hash().Bytes())
+		}
+		pubkeys, quorum, err := vmutil.ParseBlockMultiSigProgram(prev.ConsensusProgram)
+		if err != nil {
+			return errors.Wrapf(err, "parsing consensus program of block %d", prev.Height)
+		}
+		err = VerifyBlockSignatures(b, pubkeys, quorum)
+		if err != nil {
+			return err
+		}
+		prev = b
+	}
+	return nil
+}
+
+// selfTestSigner signs blocks with a private key in memory.
+type selfTestSigner struct {
+	key ed25519.PrivateKey
+}
+
+func (s selfTestSigner) SignBlock(ctx context.Context, marshalledBlock []byte) ([]byte, error) {
+	var b legacy.Block
+	err := b.UnmarshalText(marshalledBlock)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing block")
+	}
+	return ed25519.Sign(s.key, BlockSigningHash(&b).Bytes()), nil
+}
+
+func (s selfTestSigner) String() string {
+	return "self-test-signer"
+}
+
+// selfTestTx returns a tx issuing a new asset whose issuance
+// program accepts any arguments.
+func selfTestTx(initial bc.Hash) (*legacy.Tx, error) {
+	var nonce [8]byte
+	_, err := rand.Read(nonce[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "making issuance nonce")
+	}
+	prog := []byte{byte(vm.OP_TRUE)}
+	txin := legacy.NewIssuanceInput(nonce[:], 100, nil, initial, prog, nil, nil)
+	now := time.Now()
+	return legacy.NewTx(legacy.TxData{
+		Version: 1,
+		MinTime: bc.Millis(now.Add(-5 * time.Minute)),
+		MaxTime: bc.Millis(now.Add(5 * time.Minute)),
+		Inputs:  []*legacy.TxInput{txin},
+		Outputs: []*legacy.TxOutput{
+			legacy.NewTxOutput(txin.AssetID(), 100, []byte{byte(vm.OP_TRUE)}, nil),
+		},
+	}), nil
+}
@@ -0,0 +1,20 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/protocol/prottest/memstore"
+)
+
+func TestSelfTest(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New()
+	err := SelfTest(ctx, store, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := store.Height(ctx); h != 6 {
+		t.Errorf("store height after SelfTest = %d, want 6", h)
+	}
+}
@@ -0,0 +1,58 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/vm/vmutil"
+)
+
+// ErrNotNextBlock is returned by SubmitSignedBlock and ValidateBlock
+// when the block doesn't follow the generator's latest block.
+var ErrNotNextBlock = errors.New("block doesn't follow the latest block")
+
+// SubmitSignedBlock commits b, a block signed outside the
+// generator, such as one made by AssembleBlock and signed by an
+// external orchestrator. b must be the next block after the
+// latest block, or it returns ErrNotNextBlock, and it must have
+// signatures by a quorum of the latest block's consensus program,
+// or it returns ErrTooFewSignatures. It must also pass block
+// validation. Like MakeBlock, it must only be called by the
+// leader process.
+func (g *Generator) SubmitSignedBlock(ctx context.Context, b *legacy.Block) error {
+	if err := g.checkProducer(); err != nil {
+		return err
+	}
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+
+	latestBlock, latestSnapshot := g.latest()
+	if latestBlock == nil {
+		return ErrNotBootstrapped
+	}
+	if b.Height != latestBlock.Height+1 || b.PreviousBlockHash != latestBlock.Hash() {
+		return errors.WithDetailf(ErrNotNextBlock, "block %d has previous block hash %x, latest block %d is %x", b.Height, b.PreviousBlockHash.Bytes(), latestBlock.Height, latestBlock.Hash().Bytes())
+	}
+	pubkeys, quorum, err := vmutil.ParseBlockMultiSigProgram(latestBlock.ConsensusProgram)
+	if err != nil {
+		return errors.Wrap(err, "parsing consensus program")
+	}
+	err = VerifyBlockSignatures(b, pubkeys, quorum)
+	if err != nil {
+		return err
+	}
+	s, err := g.applyNextBlock(b, latestBlock, latestSnapshot)
+	if err != nil {
+		return err
+	}
+
+	ctx = blockLogContext(ctx, b)
+	err = g.commitSignedBlock(ctx, b, s, latestBlock)
+	if err != nil {
+		return err
+	}
+	g.removeFromSource(ctx, b, nil)
+	g.recordBlock(b)
+	return nil
+}
@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSubmitSignedBlock(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	_, privkeys := prottest.BlockKeyPairs(c)
+	g := New(c, nil, nil)
+	g.MaxEmptyBlockInterval = 1
+
+	b, _, err := g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.SubmitSignedBlock(ctx, b)
+	if errors.Root(err) != ErrTooFewSignatures {
+		t.Errorf("SubmitSignedBlock(unsigned block) = %v, want %v", err, ErrTooFewSignatures)
+	}
+
+	b.Witness = [][]byte{ed25519.Sign(privkeys[0], BlockSigningHash(b).Bytes())}
+	err = g.SubmitSignedBlock(ctx, b)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if h := c.Height(); h != b.Height {
+		t.Errorf("chain height = %d, want %d", h, b.Height)
+	}
+
+	err = g.SubmitSignedBlock(ctx, b)
+	if errors.Root(err) != ErrNotNextBlock {
+		t.Errorf("SubmitSignedBlock(committed block) = %v, want %v", err, ErrNotNextBlock)
+	}
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"time"
+
+	"chain/crypto/ed25519"
+)
+
+// A KeyedSigner is a BlockSigner that can report the public key
+// it signs with. The local block signer and the remote signers
+// configured by cored implement it.
+type KeyedSigner interface {
+	BlockSigner
+	PublicKey() ed25519.PublicKey
+}
+
+// A RemoteSigner is a BlockSigner that requests signatures from
+// another Chain Core, at the base URL it reports.
+type RemoteSigner interface {
+	BlockSigner
+	URL() string
+}
+
+// A SignerInfo describes one of the generator's block signers.
+type SignerInfo struct {
+	Signer BlockSigner
+	URL    string            // empty for a local signer
+	Pubkey ed25519.PublicKey // nil unless Signer is a KeyedSigner
+	Local  bool
+
+	// LastReachable is when the signer last returned a signature,
+	// or zero if it hasn't since it was configured.
+	LastReachable time.Time
+
+	// Tripped reports whether the signer's circuit breaker is
+	// open; see SignerBreakerThreshold.
+	Tripped bool
+}
+
+// Signers describes the generator's current block signers, in
+// the order they were configured, including any change made by
+// UpdateSigners.
+func (g *Generator) Signers() []SignerInfo {
+	signers, breakers, stats := g.signerState()
+	res := make([]SignerInfo, 0, len(signers))
+	for i, signer := range signers {
+		info := SignerInfo{Signer: signer, Local: true}
+		if r, ok := signer.(RemoteSigner); ok {
+			info.URL = r.URL()
+			info.Local = false
+		}
+		if k, ok := signer.(KeyedSigner); ok {
+			info.Pubkey = k.PublicKey()
+		}
+		stats[i].mu.Lock()
+		info.LastReachable = stats[i].lastSuccess
+		stats[i].mu.Unlock()
+		info.Tripped = g.breakerTripped(breakers[i])
+		res = append(res, info)
+	}
+	return res
+}
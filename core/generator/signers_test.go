@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"bytes"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+type keyedTestSigner struct{ testSigner }
+
+func (s keyedTestSigner) PublicKey() ed25519.PublicKey { return s.pubKey }
+
+type remoteTestSigner struct {
+	keyedTestSigner
+	url string
+}
+
+func (s remoteTestSigner) URL() string { return s.url }
+
+func TestSigners(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 2))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	local := keyedTestSigner{testSigner{nil, pubkeys[0], privkeys[0]}}
+	remote := remoteTestSigner{keyedTestSigner{testSigner{nil, pubkeys[1], privkeys[1]}}, "https://signer.example"}
+	g := New(c, []BlockSigner{local}, nil)
+
+	err := g.UpdateSigners([]BlockSigner{local, remote})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	infos := g.Signers()
+	if len(infos) != 2 {
+		t.Fatalf("got %d signers, want 2", len(infos))
+	}
+	if got := infos[0]; !got.Local || got.URL != "" || !bytes.Equal(got.Pubkey, pubkeys[0]) {
+		t.Errorf("local signer info = %+v, want local with key %x", got, pubkeys[0])
+	}
+	if got := infos[1]; got.Local || got.URL != remote.url || !bytes.Equal(got.Pubkey, pubkeys[1]) {
+		t.Errorf("remote signer info = %+v, want %s with key %x", got, remote.url, pubkeys[1])
+	}
+	if !infos[1].LastReachable.IsZero() {
+		t.Errorf("remote signer last reachable at %s, want never", infos[1].LastReachable)
+	}
+}
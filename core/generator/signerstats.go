@@ -0,0 +1,107 @@
+package generator
+
+import (
+	"sync"
+	"time"
+
+	"chain/errors"
+)
+
+// A SignerStat summarizes the generator's signing requests to one
+// block signer since it was configured or since ResetSignerStats.
+type SignerStat struct {
+	Signer      BlockSigner
+	Successes   int
+	Failures    int
+	LastSuccess time.Time // zero if there has been none
+
+	// ConsecutiveFailures is the number of requests that have
+	// failed since the last one that succeeded.
+	ConsecutiveFailures int
+
+	// AvgLatency is the mean time requests took, whether or not
+	// they succeeded.
+	AvgLatency time.Duration
+
+	// Tripped reports whether the signer's circuit breaker is
+	// open; see SignerBreakerThreshold.
+	Tripped bool
+}
+
+// signerStats accumulates a SignerStat for one signer.
+type signerStats struct {
+	mu           sync.Mutex
+	successes    int
+	failures     int
+	consecutive  int
+	lastSuccess  time.Time
+	totalLatency time.Duration
+	unreachable  bool // the last request failed, other than by policy
+}
+
+func newSignerStats(n int) []*signerStats {
+	s := make([]*signerStats, n)
+	for i := range s {
+		s[i] = new(signerStats)
+	}
+	return s
+}
+
+// record records a signing request that took d and finished
+// at now with err. Requests that were canceled don't count.
+func (s *signerStats) record(now time.Time, d time.Duration, err error, canceled bool) {
+	if err != nil && canceled {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.totalLatency += d
+	s.unreachable = err != nil && errors.Root(err) != ErrPolicyRejected
+	if err != nil {
+		s.failures++
+		s.consecutive++
+		return
+	}
+	s.successes++
+	s.consecutive = 0
+	s.lastSuccess = now
+}
+
+// SignerStats reports on the signing requests made to each of
+// the generator's block signers, in the order they were configured.
+func (g *Generator) SignerStats() []SignerStat {
+	signers, breakers, stats := g.signerState()
+	res := make([]SignerStat, 0, len(signers))
+	for i, signer := range signers {
+		s := stats[i]
+		s.mu.Lock()
+		st := SignerStat{
+			Signer:              signer,
+			Successes:           s.successes,
+			Failures:            s.failures,
+			ConsecutiveFailures: s.consecutive,
+			LastSuccess:         s.lastSuccess,
+		}
+		if n := s.successes + s.failures; n > 0 {
+			st.AvgLatency = s.totalLatency / time.Duration(n)
+		}
+		s.mu.Unlock()
+		st.Tripped = g.breakerTripped(breakers[i])
+		res = append(res, st)
+	}
+	return res
+}
+
+// ResetSignerStats clears the counts reported by SignerStats.
+// It doesn't reset the signers' circuit breakers.
+func (g *Generator) ResetSignerStats() {
+	_, _, stats := g.signerState()
+	for _, s := range stats {
+		s.mu.Lock()
+		s.successes, s.failures, s.consecutive = 0, 0, 0
+		s.lastSuccess = time.Time{}
+		s.totalLatency = 0
+		s.unreachable = false
+		s.mu.Unlock()
+	}
+}
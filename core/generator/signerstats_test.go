@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSignerStats(t *testing.T) {
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 2))
+	pubkeys, privkeys := prottest.BlockKeyPairs(c)
+	down := func() error { return errors.New("signer unavailable") }
+	g := New(c, []BlockSigner{
+		testSigner{nil, pubkeys[0], privkeys[0]},
+		testSigner{down, pubkeys[1], privkeys[1]},
+	}, nil)
+	g.SignerBreakerThreshold = 1
+	g.SignerBreakerCooldown = time.Hour
+
+	ctx := context.Background()
+	tip, snapshot := c.State()
+	block, _, err := c.GenerateBlock(ctx, tip, snapshot, time.Now().Add(time.Minute), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = g.getAndAddBlockSignatures(ctx, block, tip)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	stats := g.SignerStats()
+	if len(stats) != 2 {
+		t.Fatalf("got %d signer stats, want 2", len(stats))
+	}
+	// The failing signer's request may have been canceled
+	// once the working signer's signature was enough.
+	if s := stats[0]; s.Successes != 1 || s.Failures != 0 || s.LastSuccess.IsZero() || s.Tripped {
+		t.Errorf("working signer stats = %+v", s)
+	}
+	if s := stats[1]; s.Successes != 0 || s.Failures > 1 || s.ConsecutiveFailures != s.Failures || !s.LastSuccess.IsZero() {
+		t.Errorf("failing signer stats = %+v", s)
+	}
+
+	g.ResetSignerStats()
+	if s := g.SignerStats()[0]; s.Successes != 0 || !s.LastSuccess.IsZero() || s.AvgLatency != 0 {
+		t.Errorf("stats after reset = %+v", s)
+	}
+}
@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/log"
+)
+
+// blockPhases records when each phase of making a block ended,
+// for logging slow blocks.
+type blockPhases struct {
+	start     time.Time
+	assembled time.Time
+	signed    time.Time
+}
+
+func (p *blockPhases) markSigned(t time.Time) {
+	if p != nil {
+		p.signed = t
+	}
+}
+
+// slowBlockThreshold returns the effective SlowBlockThreshold.
+func (g *Generator) slowBlockThreshold() time.Duration {
+	if g.SlowBlockThreshold == 0 {
+		return g.Period() / 2
+	}
+	return g.SlowBlockThreshold
+}
+
+// logSlowBlock logs a warning with the time spent assembling,
+// signing, and committing a block that was just committed, if
+// it took longer than the slow block threshold altogether.
+// ctx should carry the block's log fields.
+func (g *Generator) logSlowBlock(ctx context.Context, p *blockPhases) {
+	threshold := g.slowBlockThreshold()
+	if threshold <= 0 {
+		return
+	}
+	end := g.now()
+	d := end.Sub(p.start)
+	if d <= threshold {
+		return
+	}
+	log.Printkv(ctx, log.KeyMessage, "slow block",
+		"duration", d,
+		"threshold", threshold,
+		"assembly", p.assembled.Sub(p.start),
+		"signing", p.signed.Sub(p.assembled),
+		"commit", end.Sub(p.signed))
+}
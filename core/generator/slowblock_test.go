@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"chain/log"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestLogSlowBlock(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stdout)
+
+	ctx := context.Background()
+	g := New(prottest.NewChain(t), nil, nil)
+	clock := &fakeClock{now: time.Now()}
+	g.Clock = clock
+	err := g.SetPeriod(4 * time.Second)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	makeBlock := func(assembly, signing, commit time.Duration) {
+		buf.Reset()
+		p := &blockPhases{start: g.now()}
+		clock.advance(assembly)
+		p.assembled = g.now()
+		clock.advance(signing)
+		p.markSigned(g.now())
+		clock.advance(commit)
+		g.logSlowBlock(ctx, p)
+	}
+
+	makeBlock(time.Second, 500*time.Millisecond, 400*time.Millisecond)
+	if buf.Len() != 0 {
+		t.Errorf("logged %q for a block within half the period", buf.String())
+	}
+
+	makeBlock(time.Second, 1500*time.Millisecond, 500*time.Millisecond)
+	got := buf.String()
+	for _, want := range []string{"slow block", "duration=3s", "threshold=2s", "assembly=1s", "signing=1.5s", "commit=500ms"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("slow block log %q doesn't contain %q", got, want)
+		}
+	}
+
+	g.SlowBlockThreshold = -1
+	makeBlock(time.Minute, time.Minute, time.Minute)
+	if buf.Len() != 0 {
+		t.Errorf("logged %q with slow block logging off", buf.String())
+	}
+}
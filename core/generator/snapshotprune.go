@@ -0,0 +1,25 @@
+package generator
+
+import (
+	"context"
+
+	"chain/log"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// compactSnapshot returns s, the state snapshot after b, or a
+// compacted copy of it if b's height is a multiple of
+// g.SnapshotPruneInterval. The copy has the same contents, and so
+// the same state root, but a newly allocated nonce set; see
+// SnapshotPruneInterval. It must be called before s is committed,
+// since the blockchain shares a committed snapshot with readers
+// that don't take g.makeMu.
+func (g *Generator) compactSnapshot(ctx context.Context, b *legacy.Block, s *state.Snapshot) *state.Snapshot {
+	if g.SnapshotPruneInterval == 0 || b.Height%g.SnapshotPruneInterval != 0 {
+		return s
+	}
+	c := state.Copy(s)
+	log.Printkv(ctx, log.KeyMessage, "compacted state snapshot", "nonces", len(c.Nonces))
+	return c
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+func TestCompactSnapshot(t *testing.T) {
+	ctx := context.Background()
+	g := New(nil, nil, nil)
+	g.SnapshotPruneInterval = 2
+
+	s := state.Empty()
+	for i := byte(0); i < 10; i++ {
+		s.Nonces[bc.NewHash([32]byte{i})] = uint64(i)
+	}
+	err := s.Tree.Insert(bc.NewHash([32]byte{1}).Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.PruneNonces(5)
+
+	b := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 3}}
+	if got := g.compactSnapshot(ctx, b, s); got != s {
+		t.Errorf("compactSnapshot at height 3 made a new snapshot")
+	}
+
+	b.Height = 4
+	got := g.compactSnapshot(ctx, b, s)
+	if got == s {
+		t.Fatal("compactSnapshot at height 4 returned the same snapshot")
+	}
+	if len(got.Nonces) != len(s.Nonces) {
+		t.Errorf("compacted snapshot has %d nonces, want %d", len(got.Nonces), len(s.Nonces))
+	}
+	for n := range s.Nonces {
+		if _, ok := got.Nonces[n]; !ok {
+			t.Errorf("compacted snapshot is missing nonce %x", n.Bytes())
+		}
+	}
+	if got.Tree.RootHash() != s.Tree.RootHash() {
+		t.Errorf("compacted state root = %x, want %x", got.Tree.RootHash().Bytes(), s.Tree.RootHash().Bytes())
+	}
+}
@@ -0,0 +1,62 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// A BlockSummary describes a block without its transactions.
+type BlockSummary struct {
+	Height   uint64    `json:"height"`
+	Hash     bc.Hash   `json:"hash"`
+	Time     time.Time `json:"timestamp"`
+	TxCount  int       `json:"transaction_count"`
+	ByteSize int       `json:"byte_size"` // size of the serialized block
+}
+
+// GetBlockSummaries returns summaries of the blocks with heights
+// greater than afterHeight, in height order. Like GetBlocks, it
+// returns at most limit of them, or DefaultBlocksLimit if limit is
+// zero, but it doesn't wait for new blocks.
+//
+// It reads only block headers and sizes from storage, which is much
+// cheaper than reading and decoding whole blocks.
+func (g *Generator) GetBlockSummaries(ctx context.Context, afterHeight uint64, limit int) ([]BlockSummary, error) {
+	if limit <= 0 {
+		limit = DefaultBlocksLimit
+	}
+	if err := g.checkDB(); err != nil {
+		return nil, err
+	}
+	release, err := g.startBlockRead(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	summaries := []BlockSummary{}
+	err = g.store.ListBlockHeaders(ctx, afterHeight, limit, func(header *legacy.BlockHeader, size int, txCount uint64) error {
+		summaries = append(summaries, summarizeBlock(header, size, txCount))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "getting block summaries")
+	}
+	return summaries, nil
+}
+
+// summarizeBlock returns the summary of a block with the given
+// header, serialized size, and number of transactions.
+func summarizeBlock(header *legacy.BlockHeader, size int, txCount uint64) BlockSummary {
+	return BlockSummary{
+		Height:   header.Height,
+		Hash:     header.Hash(),
+		Time:     header.Time(),
+		TxCount:  int(txCount),
+		ByteSize: size,
+	}
+}
@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/core/txdb"
+	"chain/database/pg/pgtest"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestGetBlockSummaries(t *testing.T) {
+	ctx := context.Background()
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	c := prottest.NewChain(t, prottest.WithStore(txdb.NewStore(db)))
+	initial := prottest.Initial(t, c).Hash()
+	prottest.MakeBlock(t, c, nil)
+	b3 := prottest.MakeBlock(t, c, []*legacy.Tx{bctest.NewIssuanceTx(t, initial)})
+	g := New(c, nil, db)
+
+	summaries, err := g.GetBlockSummaries(ctx, 2, 0)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(summaries))
+	}
+	if s := summaries[0]; s.Height != 3 || s.Hash != b3.Hash() || s.TxCount != 1 {
+		t.Errorf("summary = %+v, want block 3 with 1 tx", s)
+	}
+
+	summaries, err = g.GetBlockSummaries(ctx, 0, 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(summaries) != 2 || summaries[0].Height != 1 || summaries[1].TxCount != 0 {
+		t.Errorf("summaries = %+v, want blocks 1 and 2, with no txs", summaries)
+	}
+}
@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// ErrNilTip is returned by SetTip when the block or its snapshot
+// is nil.
+var ErrNilTip = errors.New("tip needs a block and its snapshot")
+
+// SetTip makes the generator treat b, with resulting state s, as
+// the latest block, regardless of the blockchain's own state. b
+// must already be committed, and neither b nor s may be nil;
+// ClearTip goes back to following the blockchain.
+//
+// SetTip is meant for tests, and for advanced recovery by an
+// operator who knows what it does: it lets a generator build on a
+// block other than the blockchain's latest, for example to
+// simulate a new leader that is behind. Don't use it otherwise.
+//
+// On a tip behind the blockchain's latest block, the generator
+// makes and signs its next block, but committing it fails: the
+// blockchain already has a block at that height, and its storage
+// refuses a second one. So MakeBlock returns an error, Generate
+// reports one each period, and the blockchain is unchanged.
+func (g *Generator) SetTip(ctx context.Context, b *legacy.Block, s *state.Snapshot) error {
+	if b == nil || s == nil {
+		return ErrNilTip
+	}
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+
+	stored, err := g.chain.GetBlock(ctx, b.Height)
+	if err != nil {
+		return errors.Wrapf(err, "getting block at height %d", b.Height)
+	}
+	if stored.Hash() != b.Hash() {
+		return errors.WithDetailf(ErrBlockNotFound, "the committed block at height %d has a different hash", b.Height)
+	}
+
+	g.tipMu.Lock()
+	g.tipBlock, g.tipSnapshot = b, s
+	g.tipMu.Unlock()
+	return nil
+}
+
+// ClearTip undoes SetTip, so the generator builds on the
+// blockchain's latest block again.
+func (g *Generator) ClearTip() {
+	g.makeMu.Lock()
+	defer g.makeMu.Unlock()
+	g.tipMu.Lock()
+	g.tipBlock, g.tipSnapshot = nil, nil
+	g.tipMu.Unlock()
+}
+
+// latest returns the block the generator builds on, and its state:
+// the blockchain's latest, unless overridden by SetTip.
+func (g *Generator) latest() (*legacy.Block, *state.Snapshot) {
+	g.tipMu.Lock()
+	defer g.tipMu.Unlock()
+	if g.tipBlock != nil {
+		return g.tipBlock, g.tipSnapshot
+	}
+	return g.chain.State()
+}
+
+// advanceTip moves a tip set by SetTip to b, once b is committed.
+func (g *Generator) advanceTip(b *legacy.Block, s *state.Snapshot) {
+	g.tipMu.Lock()
+	defer g.tipMu.Unlock()
+	if g.tipBlock != nil {
+		g.tipBlock, g.tipSnapshot = b, s
+	}
+}
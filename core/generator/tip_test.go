@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/core/txdb"
+	"chain/database/pg/pgtest"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/protocol/prottest/memstore"
+	"chain/protocol/state"
+	"chain/testutil"
+)
+
+func TestSetTip(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	b2 := prottest.MakeBlock(t, c, nil)
+	_, s2 := c.State()
+	prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, nil)
+
+	err := g.SetTip(ctx, b2, s2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if got := g.LatestBlock(); got.Hash() != b2.Hash() {
+		t.Errorf("LatestBlock() after SetTip = block %d, want block 2", got.Height)
+	}
+
+	// The tip follows blocks the generator commits.
+	g.makeMu.Lock()
+	b3, s3, err := c.GenerateBlock(ctx, b2, s2, b2.Time().Add(1), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	g.advanceTip(b3, s3)
+	g.makeMu.Unlock()
+	if got := g.LatestBlock(); got != b3 {
+		t.Errorf("LatestBlock() after advanceTip = block %d, want the new block 3", got.Height)
+	}
+
+	other := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 2, TimestampMS: 1}}
+	err = g.SetTip(ctx, other, s2)
+	if errors.Root(err) != ErrBlockNotFound {
+		t.Errorf("SetTip(uncommitted block) = %v, want %v", err, ErrBlockNotFound)
+	}
+
+	nils := []struct {
+		b *legacy.Block
+		s *state.Snapshot
+	}{{nil, nil}, {b2, nil}, {nil, s2}}
+	for _, tip := range nils {
+		err = g.SetTip(ctx, tip.b, tip.s)
+		if err != ErrNilTip {
+			t.Errorf("SetTip(block %t, snapshot %t) = %v, want %v", tip.b != nil, tip.s != nil, err, ErrNilTip)
+		}
+	}
+
+	g.ClearTip()
+	if got, _ := c.State(); g.LatestBlock() != got {
+		t.Errorf("LatestBlock() after clearing the tip = block %d, want block %d", g.LatestBlock().Height, got.Height)
+	}
+}
+
+func TestSetTipBehind(t *testing.T) {
+	testSetTipBehind(t, memstore.New())
+}
+
+func TestSetTipBehindTxdb(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	testSetTipBehind(t, txdb.NewStore(db))
+}
+
+// testSetTipBehind sets the generator's tip behind the latest
+// block in store, as for a leader that is behind, and checks that
+// making a block on it fails to commit and leaves the blockchain
+// as it was.
+func testSetTipBehind(t *testing.T, store protocol.Store) {
+	ctx := context.Background()
+	c := prottest.NewChain(t, prottest.WithStore(store))
+	b2 := prottest.MakeBlock(t, c, nil)
+	_, s2 := c.State()
+	b3 := prottest.MakeBlock(t, c, nil)
+	g := New(c, nil, nil)
+
+	err := g.SetTip(ctx, b2, s2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = g.MakeBlock(ctx)
+	if err == nil {
+		t.Fatal("MakeBlock() on a tip behind the latest block succeeded, want an error")
+	}
+	stored, err := store.GetBlock(ctx, 3)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if stored.Hash() != b3.Hash() {
+		t.Errorf("stored block 3 changed after MakeBlock on an old tip")
+	}
+	if h := c.Height(); h != 3 {
+		t.Errorf("chain height = %d, want 3", h)
+	}
+	if got := g.LatestBlock(); got.Hash() != b2.Hash() {
+		t.Errorf("LatestBlock() = block %d, want block 2", got.Height)
+	}
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/net/trace"
+)
+
+// A span is a trace of one step of making a block, shown at
+// /debug/requests by golang.org/x/net/trace. The zero span, used
+// when tracing is off, does nothing.
+type span struct {
+	tr trace.Trace
+}
+
+// startSpan returns a span in the given trace family, if
+// g.EnableTracing is set, and ctx carrying it. A span with a parent
+// span in ctx is also noted in the parent's event log.
+func (g *Generator) startSpan(ctx context.Context, family, title string) (context.Context, span) {
+	if !g.EnableTracing {
+		return ctx, span{}
+	}
+	if parent, ok := trace.FromContext(ctx); ok {
+		parent.LazyPrintf("started %s %s", family, title)
+	}
+	tr := trace.New(family, title)
+	return trace.NewContext(ctx, tr), span{tr}
+}
+
+func (s span) printf(format string, args ...interface{}) {
+	if s.tr != nil {
+		s.tr.LazyPrintf(format, args...)
+	}
+}
+
+// finish ends s, marking it as failed if err is not nil.
+func (s span) finish(err error) {
+	if s.tr == nil {
+		return
+	}
+	if err != nil {
+		s.tr.LazyPrintf("error: %s", err)
+		s.tr.SetError()
+	}
+	s.tr.Finish()
+}
+
+// signerTitle is the trace title for requests to signer.
+func signerTitle(signer BlockSigner) string {
+	if r, ok := signer.(RemoteSigner); ok {
+		return redactURL(r.URL())
+	}
+	return fmt.Sprint(signer)
+}
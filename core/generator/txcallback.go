@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	"chain/log"
+	"chain/protocol/bc/legacy"
+)
+
+// An acceptedTx is a tx queued for OnTxAccepted.
+type acceptedTx struct {
+	ctx context.Context
+	tx  *legacy.Tx
+}
+
+// txAccepted calls g.OnTxAccepted, if set, for tx, either directly
+// or, if g.TxCallbackWorkers is set, by queueing tx for
+// runTxCallbacks. A queued callback gets a ctx that's not canceled
+// when the submission returns.
+func (g *Generator) txAccepted(ctx context.Context, tx *legacy.Tx) {
+	if g.OnTxAccepted == nil {
+		return
+	}
+	if g.TxCallbackWorkers <= 0 {
+		g.callOnTxAccepted(ctx, tx)
+		return
+	}
+
+	g.txQueueOnce.Do(func() {
+		g.txQueue = make(chan acceptedTx, g.TxCallbackBuffer)
+		for i := 0; i < g.TxCallbackWorkers; i++ {
+			go g.runTxCallbacks()
+		}
+	})
+	select {
+	case g.txQueue <- acceptedTx{detachedContext{ctx}, tx}:
+	default:
+		log.Printkv(ctx, log.KeyMessage, "tx callback queue is full; skipping tx accepted hook", "tx", fmt.Sprintf("%x", tx.ID.Bytes()))
+		g.recordDroppedTxCallback()
+	}
+}
+
+// runTxCallbacks calls g.OnTxAccepted for each tx in g.txQueue.
+// It runs for the life of the process.
+func (g *Generator) runTxCallbacks() {
+	for a := range g.txQueue {
+		g.callOnTxAccepted(a.ctx, a.tx)
+	}
+}
+
+func (g *Generator) callOnTxAccepted(ctx context.Context, tx *legacy.Tx) {
+	err := g.OnTxAccepted(ctx, tx)
+	if err != nil {
+		log.Printkv(ctx, log.KeyMessage, "tx accepted hook failed", "tx", fmt.Sprintf("%x", tx.ID.Bytes()), log.KeyError, err)
+	}
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+func TestOnTxAccepted(t *testing.T) {
+	ctx := context.Background()
+	var got []byte
+	g := New(nil, nil, nil)
+	g.OnTxAccepted = func(ctx context.Context, tx *legacy.Tx) error {
+		got = append(got, tx.ID.Bytes()[0])
+		return errors.New("hook failed")
+	}
+
+	err := g.Submit(ctx, testTx(1, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Submit(ctx, testTx(1, nil, nil)) // duplicate
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{testTx(2, nil, nil), testTx(1, nil, nil), testTx(3, nil, nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[1] != ErrDuplicateTx {
+		t.Errorf("SubmitBatch error for a duplicate = %v, want %v", errs[1], ErrDuplicateTx)
+	}
+	if string(got) != "\x01\x02\x03" {
+		t.Errorf("OnTxAccepted got txs %x, want 010203", got)
+	}
+}
+
+func TestAsyncOnTxAccepted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	ids := make(chan bc.Hash, 10)
+
+	g := New(nil, nil, nil)
+	g.TxCallbackWorkers = 1
+	g.TxCallbackBuffer = 1
+	g.OnTxAccepted = func(ctx context.Context, tx *legacy.Tx) error {
+		started <- struct{}{}
+		<-release
+		if ctx.Err() != nil {
+			t.Errorf("OnTxAccepted ctx error = %v after submission returned", ctx.Err())
+		}
+		ids <- tx.ID
+		return nil
+	}
+
+	submit := func(id byte) {
+		err := g.Submit(ctx, testTx(id, nil, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	submit(1) // the worker starts on tx 1 and waits
+	<-started
+	submit(2) // tx 2 is queued
+	submit(3) // the queue is full, so tx 3 is dropped
+	cancel()
+
+	close(release)
+	var got []byte
+	for len(got) < 2 {
+		got = append(got, (<-ids).Bytes()[0])
+	}
+	select {
+	case id := <-ids:
+		got = append(got, id.Bytes()[0])
+	case <-time.After(10 * time.Millisecond):
+	}
+	if string(got) != "\x01\x02" {
+		t.Errorf("OnTxAccepted got txs %x, want 0102", got)
+	}
+	if n := len(g.PendingTxs()); n != 3 {
+		t.Errorf("pending txs = %d, want 3", n)
+	}
+}
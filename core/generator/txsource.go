@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"context"
+	"io/ioutil"
+
+	"chain/errors"
+	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// A TxSource holds pending transactions for the generator in place
+// of its built-in pending tx pool, for example one fed from an
+// external queue. See Generator.TxSource.
+type TxSource interface {
+	// Pending returns the pending txs, in topological order:
+	// a tx must come after any other pending tx whose outputs
+	// it spends.
+	Pending(ctx context.Context) ([]*legacy.Tx, error)
+
+	// Add adds tx to the pending txs. It may return
+	// ErrDuplicateTx if tx is already pending.
+	Add(ctx context.Context, tx *legacy.Tx) error
+
+	// Remove removes the txs with the given IDs, once the generator
+	// has put them in a committed block or given up on them.
+	// IDs that aren't pending are ignored.
+	Remove(ctx context.Context, ids []bc.Hash) error
+}
+
+// addToSource adds tx to g.TxSource, observing MaxTxBytes.
+func (g *Generator) addToSource(ctx context.Context, tx *legacy.Tx) error {
+	err := g.checkTxSize(tx)
+	if err != nil {
+		return err
+	}
+	err = g.TxSource.Add(ctx, tx)
+	if err != nil {
+		return err
+	}
+	g.noteSubmitted(tx.ID)
+	return nil
+}
+
+// removeFromSource removes the txs that were taken for b, which has
+// been committed, from g.TxSource. That includes any that weren't
+// valid for b. If no txs were taken, because b was left pending by
+// an earlier leader, it removes b's txs. A failure is logged; the
+// txs may be offered again, but can't go into another block.
+func (g *Generator) removeFromSource(ctx context.Context, b *legacy.Block, taken []*legacy.Tx) {
+	if g.TxSource == nil {
+		return
+	}
+	if taken == nil {
+		taken = b.Transactions
+	}
+	ids := make([]bc.Hash, 0, len(taken))
+	for _, tx := range taken {
+		ids = append(ids, tx.ID)
+	}
+	err := g.TxSource.Remove(ctx, ids)
+	if err != nil {
+		log.Printkv(ctx, log.KeyMessage, "removing committed txs from tx source failed", log.KeyError, err)
+	}
+}
+
+// checkTxSize returns ErrTxTooLarge if tx is bigger than MaxTxBytes.
+func (g *Generator) checkTxSize(tx *legacy.Tx) error {
+	if g.MaxTxBytes <= 0 {
+		return nil
+	}
+	n, err := tx.WriteTo(ioutil.Discard)
+	if err != nil {
+		return errors.Wrap(err, "serializing tx")
+	}
+	if n > int64(g.MaxTxBytes) {
+		return errors.WithDetailf(ErrTxTooLarge, "transaction is %d bytes; the limit is %d", n, g.MaxTxBytes)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+// sliceSource is a TxSource backed by a slice.
+type sliceSource struct {
+	mu  sync.Mutex
+	txs []*legacy.Tx
+}
+
+func (s *sliceSource) Pending(context.Context) ([]*legacy.Tx, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*legacy.Tx(nil), s.txs...), nil
+}
+
+func (s *sliceSource) Add(ctx context.Context, tx *legacy.Tx) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range s.txs {
+		if p.ID == tx.ID {
+			return ErrDuplicateTx
+		}
+	}
+	s.txs = append(s.txs, tx)
+	return nil
+}
+
+func (s *sliceSource) Remove(ctx context.Context, ids []bc.Hash) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	remove := make(map[bc.Hash]bool)
+	for _, id := range ids {
+		remove[id] = true
+	}
+	var keep []*legacy.Tx
+	for _, tx := range s.txs {
+		if !remove[tx.ID] {
+			keep = append(keep, tx)
+		}
+	}
+	s.txs = keep
+	return nil
+}
+
+func TestTxSource(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	tx1 := bctest.NewIssuanceTx(t, initial)
+	tx2 := bctest.NewIssuanceTx(t, initial)
+
+	source := new(sliceSource)
+	g := New(c, nil, nil)
+	g.TxSource = source
+	g.MaxTxPerBlock = 1
+
+	errs, err := g.SubmitBatch(ctx, []*legacy.Tx{tx1, tx2, tx1})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if errs[0] != nil || errs[1] != nil || errs[2] != ErrDuplicateTx {
+		t.Errorf("SubmitBatch errors = %v, want [<nil> <nil> %v]", errs, ErrDuplicateTx)
+	}
+	if n := len(g.PendingTxs()); n != 0 {
+		t.Errorf("built-in pool has %d txs, want 0", n)
+	}
+
+	b, _, err := g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(b.Transactions) != 1 || b.Transactions[0].ID != tx1.ID {
+		t.Fatalf("assembled block has %d txs, want only tx1", len(b.Transactions))
+	}
+
+	g.removeFromSource(ctx, b, b.Transactions)
+	pending, _ := source.Pending(ctx)
+	if len(pending) != 1 || pending[0].ID != tx2.ID {
+		t.Errorf("source has %d pending txs after removal, want only tx2", len(pending))
+	}
+}
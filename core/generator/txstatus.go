@@ -0,0 +1,123 @@
+package generator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// TxStatus describes what has become of a submitted tx.
+type TxStatus int
+
+const (
+	// Unknown means the tx is neither pending nor known to be
+	// in a committed block.
+	Unknown TxStatus = iota
+
+	// Pending means the tx is in the pending tx pool, or in a
+	// block the generator hasn't committed yet.
+	Pending
+
+	// Committed means the tx is in a committed block.
+	Committed
+)
+
+func (s TxStatus) String() string {
+	switch s {
+	case Unknown:
+		return "unknown"
+	case Pending:
+		return "pending"
+	case Committed:
+		return "committed"
+	}
+	return fmt.Sprintf("TxStatus(%d)", int(s))
+}
+
+// TxStatus reports whether the tx with the given ID is pending or
+// committed, and if it's committed, the height of its block.
+//
+// Committed txs are looked up in the annotated_txs table, which is
+// filled in by the query indexer some time after each block is
+// committed, and is indexed on tx_hash for this purpose. Unless
+// includePending is set, a tx that is in a block being made, or in
+// a block not yet indexed, is reported as Unknown.
+//
+// A client that wants to read its own writes, so that a tx it has
+// just submitted doesn't seem to vanish between Submit and the
+// indexing of its block, should set includePending. Then a tx is
+// also Pending if it's in the TxSource or in the block being signed
+// and committed, and Committed if it's in the generator's latest
+// block, indexed or not. Leaving it unset skips those checks, which
+// for a TxSource means listing all its pending txs.
+func (g *Generator) TxStatus(ctx context.Context, id bc.Hash, includePending bool) (TxStatus, uint64, error) {
+	g.mu.Lock()
+	_, ok := g.poolTimes[id]
+	g.mu.Unlock()
+	if ok {
+		return Pending, 0, nil
+	}
+	if includePending {
+		status, height, err := g.unindexedTxStatus(ctx, id)
+		if err != nil || status != Unknown {
+			return status, height, err
+		}
+	}
+	if g.db == nil {
+		return Unknown, 0, nil
+	}
+
+	const q = `SELECT block_height FROM annotated_txs WHERE tx_hash = $1`
+	var height uint64
+	err := g.db.QueryRowContext(ctx, q, id.Bytes()).Scan(&height)
+	if err == sql.ErrNoRows {
+		return Unknown, 0, nil
+	}
+	if err != nil {
+		return Unknown, 0, errors.Wrap(err, "annotated_txs query")
+	}
+	return Committed, height, nil
+}
+
+// unindexedTxStatus is the status of the tx with the given ID as
+// the generator knows it without annotated_txs and apart from the
+// pending tx pool: Pending if it's in the TxSource or the block
+// being made, Committed if it's in the latest block, and otherwise
+// Unknown.
+func (g *Generator) unindexedTxStatus(ctx context.Context, id bc.Hash) (TxStatus, uint64, error) {
+	if g.TxSource != nil {
+		txs, err := g.TxSource.Pending(ctx)
+		if err != nil {
+			return Unknown, 0, errors.Wrap(err, "getting pending txs")
+		}
+		if hasTx(txs, id) {
+			return Pending, 0, nil
+		}
+	}
+
+	latest, _ := g.latest()
+	if latest != nil && hasTx(latest.Transactions, id) {
+		return Committed, latest.Height, nil
+	}
+	b, err := g.loadPendingBlock(ctx)
+	if err != nil {
+		return Unknown, 0, err
+	}
+	if b != nil && (latest == nil || b.Height > latest.Height) && hasTx(b.Transactions, id) {
+		return Pending, 0, nil
+	}
+	return Unknown, 0, nil
+}
+
+func hasTx(txs []*legacy.Tx, id bc.Hash) bool {
+	for _, tx := range txs {
+		if tx.ID == id {
+			return true
+		}
+	}
+	return false
+}
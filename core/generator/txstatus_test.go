@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/database/pg/pgtest"
+	"chain/protocol/bc"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestTxStatus(t *testing.T) {
+	ctx := context.Background()
+	dbtx := pgtest.NewTx(t)
+	c := prottest.NewChain(t)
+	g := New(c, nil, dbtx)
+
+	pending := testTx(1, nil, nil)
+	committed := bc.NewHash([32]byte{2})
+	unknown := bc.NewHash([32]byte{3})
+	g.pool = append(g.pool, pending)
+	g.poolTimes[pending.ID] = time.Now()
+
+	const q = `
+		INSERT INTO annotated_txs (block_height, tx_pos, tx_hash, data, "timestamp", block_id, local, reference_data)
+		VALUES (7, 0, $1, '{}', now(), '\x00', true, '{}')
+	`
+	_, err := dbtx.ExecContext(ctx, q, committed.Bytes())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	cases := []struct {
+		id             bc.Hash
+		includePending bool
+		status         TxStatus
+		height         uint64
+	}{
+		{pending.ID, true, Pending, 0},
+		{pending.ID, false, Pending, 0},
+		{committed, true, Committed, 7},
+		{committed, false, Committed, 7},
+		{unknown, true, Unknown, 0},
+	}
+	for _, c := range cases {
+		status, height, err := g.TxStatus(ctx, c.id, c.includePending)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		if status != c.status || height != c.height {
+			t.Errorf("TxStatus(%x, %t) = %v, %d, want %v, %d", c.id.Bytes(), c.includePending, status, height, c.status, c.height)
+		}
+	}
+}
+
+func TestTxStatusLatestBlock(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	tx := bctest.NewIssuanceTx(t, prottest.Initial(t, c).Hash())
+	b := prottest.MakeBlock(t, c, []*legacy.Tx{tx})
+
+	status, height, err := g.TxStatus(ctx, tx.ID, true)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if status != Committed || height != b.Height {
+		t.Errorf("TxStatus(tx in latest block) = %v, %d, want %v, %d", status, height, Committed, b.Height)
+	}
+
+	status, _, err = g.TxStatus(ctx, tx.ID, false)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if status != Unknown {
+		t.Errorf("TxStatus(unindexed tx, false) = %v, want %v", status, Unknown)
+	}
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"runtime"
+	"sync"
+
+	"chain/protocol/bc/legacy"
+)
+
+// prevalidateTxs validates txs, which are about to go into a
+// block, on up to ValidationWorkers goroutines. The blockchain
+// caches the results, so when GenerateBlock goes through the txs
+// in order, it doesn't validate them again and only applies them
+// to the state.
+//
+// This is safe to do in parallel because a tx is validated on its
+// own, without the state. Checks that depend on txs before it,
+// such as spending an output of an earlier tx in the same block,
+// happen as GenerateBlock applies the txs one at a time, so a tx
+// that spends an output of a later tx is still left out.
+func (g *Generator) prevalidateTxs(txs []*legacy.Tx) {
+	n := g.ValidationWorkers
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	if n > len(txs) {
+		n = len(txs)
+	}
+	if n <= 1 {
+		return // GenerateBlock will validate them
+	}
+
+	work := make(chan *legacy.Tx)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			for tx := range work {
+				g.chain.ValidateTx(tx.Tx) // the error is cached for GenerateBlock
+			}
+		}()
+	}
+	for _, tx := range txs {
+		work <- tx
+	}
+	close(work)
+	wg.Wait()
+}
@@ -0,0 +1,76 @@
+package generator
+
+import (
+	"context"
+	"crypto/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/protocol/vm"
+	"chain/testutil"
+)
+
+// issueAndSpend returns a tx that issues a new asset to OP_TRUE
+// and a tx that spends its output.
+func issueAndSpend(t *testing.T, initial bc.Hash) (issue, spend *legacy.Tx) {
+	var nonce [8]byte
+	_, err := rand.Read(nonce[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	trueProg := []byte{byte(vm.OP_TRUE)}
+	txin := legacy.NewIssuanceInput(nonce[:], 100, nil, initial, trueProg, nil, nil)
+	issue = legacy.NewTx(legacy.TxData{
+		Version: 1,
+		MinTime: bc.Millis(time.Now().Add(-5 * time.Minute)),
+		MaxTime: bc.Millis(time.Now().Add(5 * time.Minute)),
+		Inputs:  []*legacy.TxInput{txin},
+		Outputs: []*legacy.TxOutput{legacy.NewTxOutput(txin.AssetID(), 100, trueProg, nil)},
+	})
+
+	out, err := issue.Output(*issue.ResultIds[0])
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	spend = legacy.NewTx(legacy.TxData{
+		Version: 1,
+		Inputs: []*legacy.TxInput{
+			legacy.NewSpendInput(nil, *out.Source.Ref, txin.AssetID(), 100, out.Source.Position, trueProg, *out.Data, nil),
+		},
+		Outputs: []*legacy.TxOutput{legacy.NewTxOutput(txin.AssetID(), 100, trueProg, nil)},
+	})
+	return issue, spend
+}
+
+func TestValidationWorkers(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+
+	var pool []*legacy.Tx
+	for i := 0; i < 4; i++ {
+		issue, spend := issueAndSpend(t, initial)
+		pool = append(pool, issue, spend)
+	}
+	// A tx can only spend the output of a tx before it in the block.
+	issue, spend := issueAndSpend(t, initial)
+	pool = append(pool, spend, issue)
+
+	for _, workers := range []int{1, 4} {
+		g := New(c, nil, nil)
+		g.ValidationWorkers = workers
+		g.pool = pool
+		b, _, err := g.AssembleBlock(ctx)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		want := append(pool[:8:8], issue)
+		if !reflect.DeepEqual(b.Transactions, want) {
+			t.Errorf("with %d workers, block txs = %v, want %v", workers, b.Transactions, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"context"
+
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// ValidateBlock reports whether b, a block made elsewhere, would
+// be accepted as the next block after the generator's latest
+// block. It validates b, including its signatures, and applies it
+// to a copy of the latest snapshot, returning the resulting
+// snapshot. It doesn't commit b or change the generator's state,
+// so, unlike SubmitSignedBlock, it may be called by any process.
+func (g *Generator) ValidateBlock(ctx context.Context, b *legacy.Block) (*state.Snapshot, error) {
+	latestBlock, latestSnapshot := g.latest()
+	if latestBlock == nil {
+		return nil, ErrNotBootstrapped
+	}
+	if b.Height != latestBlock.Height+1 || b.PreviousBlockHash != latestBlock.Hash() {
+		return nil, errors.WithDetailf(ErrNotNextBlock, "block %d has previous block hash %x, latest block %d is %x", b.Height, b.PreviousBlockHash.Bytes(), latestBlock.Height, latestBlock.Hash().Bytes())
+	}
+	return g.applyNextBlock(b, latestBlock, latestSnapshot)
+}
+
+// applyNextBlock validates b, which follows prev, and returns the
+// result of applying it to a copy of snapshot, the state after prev.
+func (g *Generator) applyNextBlock(b, prev *legacy.Block, snapshot *state.Snapshot) (*state.Snapshot, error) {
+	err := g.checkBlockVersion(b)
+	if err != nil {
+		return nil, err
+	}
+	err = g.chain.ValidateBlock(b, prev)
+	if err != nil {
+		return nil, errors.Wrap(err, "validating block")
+	}
+	s := state.Copy(snapshot)
+	err = s.ApplyBlock(legacy.MapBlock(b))
+	if err != nil {
+		return nil, errors.Wrap(err, "applying block")
+	}
+	if b.AssetsMerkleRoot != s.Tree.RootHash() {
+		return nil, protocol.ErrBadStateRoot
+	}
+	return s, nil
+}
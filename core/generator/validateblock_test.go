@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestValidateBlock(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t, prottest.WithBlockSigners(1, 1))
+	_, privkeys := prottest.BlockKeyPairs(c)
+	g := New(c, nil, nil)
+	g.MaxEmptyBlockInterval = 1
+
+	b, _, err := g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = g.ValidateBlock(ctx, b)
+	if errors.Root(err) != protocol.ErrBadBlock {
+		t.Errorf("ValidateBlock(unsigned block) = %v, want %v", err, protocol.ErrBadBlock)
+	}
+
+	b.Witness = [][]byte{ed25519.Sign(privkeys[0], BlockSigningHash(b).Bytes())}
+	prevBlock, prevSnapshot := g.latest()
+	s, err := g.ValidateBlock(ctx, b)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if s.Tree.RootHash() != b.AssetsMerkleRoot {
+		t.Errorf("snapshot root = %x, want %x", s.Tree.RootHash().Bytes(), b.AssetsMerkleRoot.Bytes())
+	}
+	if latest, snapshot := g.latest(); latest != prevBlock || snapshot != prevSnapshot || s == prevSnapshot {
+		t.Error("ValidateBlock changed the generator's latest block or snapshot")
+	}
+	if h := c.Height(); h != prevBlock.Height {
+		t.Errorf("chain height = %d, want %d", h, prevBlock.Height)
+	}
+
+	b.Height++
+	_, err = g.ValidateBlock(ctx, b)
+	if errors.Root(err) != ErrNotNextBlock {
+		t.Errorf("ValidateBlock(wrong height) = %v, want %v", err, ErrNotNextBlock)
+	}
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrTooFewSignatures is returned by VerifyBlockSignatures when a
+// block doesn't have enough valid signatures.
+var ErrTooFewSignatures = errors.New("block has too few valid signatures")
+
+// VerifyBlockSignatures checks that b carries valid signatures by
+// at least quorum of pubkeys, the keys of the consensus program of
+// the block before b. It doesn't validate anything else about b.
+// Signatures that don't verify with any of pubkeys are ignored, and
+// more than one signature by the same key counts once.
+//
+// It's for clients of GetBlocks that want to check where blocks
+// came from without applying them. Signatures are checked against
+// BlockSigningHash(b).
+func VerifyBlockSignatures(b *legacy.Block, pubkeys []ed25519.PublicKey, quorum int) error {
+	msg := BlockSigningHash(b).Bytes()
+	signed := make([]bool, len(pubkeys))
+	n := 0
+	for _, sig := range b.Witness {
+		k := indexKey(pubkeys, msg, sig)
+		if k >= 0 && !signed[k] {
+			signed[k] = true
+			n++
+		}
+	}
+	if n < quorum {
+		return errors.WithDetailf(ErrTooFewSignatures, "block %d has %d of %d needed signatures", b.Height, n, quorum)
+	}
+	return nil
+}
+
+// BlockSigningHash returns the hash that block signers sign for b.
+// It's the hash of the block header, not including the witness,
+// so signatures can be added to b without changing it. A signer
+// signs its bytes with ed25519, and the generator checks each
+// signature against it.
+func BlockSigningHash(b *legacy.Block) bc.Hash {
+	return b.BlockHeader.Hash()
+}
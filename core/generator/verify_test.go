@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"fmt"
+	"testing"
+
+	"chain/crypto/ed25519"
+	"chain/errors"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
+)
+
+func TestBlockSigningHash(t *testing.T) {
+	b := &legacy.Block{BlockHeader: legacy.BlockHeader{
+		Version:           1,
+		Height:            2,
+		PreviousBlockHash: bc.NewHash([32]byte{1}),
+		TimestampMS:       1500000000000,
+		BlockCommitment: legacy.BlockCommitment{
+			TransactionsMerkleRoot: bc.NewHash([32]byte{2}),
+			AssetsMerkleRoot:       bc.NewHash([32]byte{3}),
+			ConsensusProgram:       []byte{0x51},
+		},
+	}}
+	const want = "daf396d374dbb3e1bc391a250ceaa7a9b7156f4f34dbeec4bc5f18efb83fcee9"
+
+	h := BlockSigningHash(b)
+	if got := fmt.Sprintf("%x", h.Bytes()); got != want {
+		t.Errorf("BlockSigningHash() = %s, want %s", got, want)
+	}
+	b.Witness = [][]byte{{1, 2, 3}}
+	if BlockSigningHash(b) != h {
+		t.Error("BlockSigningHash() changed when a signature was added")
+	}
+}
+
+func TestVerifyBlockSignatures(t *testing.T) {
+	var pubkeys []ed25519.PublicKey
+	var privkeys []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		pub, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubkeys = append(pubkeys, pub)
+		privkeys = append(privkeys, priv)
+	}
+	b := &legacy.Block{BlockHeader: legacy.BlockHeader{Height: 2, TimestampMS: 1000}}
+	msg := BlockSigningHash(b).Bytes()
+	sig0 := ed25519.Sign(privkeys[0], msg)
+	sig1 := ed25519.Sign(privkeys[1], msg)
+
+	cases := []struct {
+		witness [][]byte
+		wantErr error
+	}{
+		{[][]byte{sig0, sig1}, nil},
+		{[][]byte{sig1}, ErrTooFewSignatures},
+		{[][]byte{sig0, sig0}, ErrTooFewSignatures},
+		{[][]byte{sig0, []byte("bogus")}, ErrTooFewSignatures},
+		{nil, ErrTooFewSignatures},
+	}
+	for i, c := range cases {
+		b.Witness = c.witness
+		err := VerifyBlockSignatures(b, pubkeys, 2)
+		if errors.Root(err) != c.wantErr {
+			t.Errorf("case %d: VerifyBlockSignatures() = %v, want %v", i, err, c.wantErr)
+		}
+	}
+
+	// A signature over a different block doesn't count.
+	b.Witness = [][]byte{sig0, sig1}
+	b.Height = 3
+	if err := VerifyBlockSignatures(b, pubkeys, 1); errors.Root(err) != ErrTooFewSignatures {
+		t.Errorf("VerifyBlockSignatures(altered block) = %v, want %v", err, ErrTooFewSignatures)
+	}
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/state"
+)
+
+// ErrUnsupportedBlockVersion is returned when a block has a higher
+// version than the generator's BlockVersion, so the generator
+// can't build on it or commit it.
+var ErrUnsupportedBlockVersion = errors.New("unsupported block version")
+
+// blockVersion returns the version of the blocks g makes.
+func (g *Generator) blockVersion() uint64 {
+	if g.BlockVersion == 0 {
+		return 1
+	}
+	return g.BlockVersion
+}
+
+// checkBlockVersion returns ErrUnsupportedBlockVersion if b's
+// version is higher than g's.
+func (g *Generator) checkBlockVersion(b *legacy.Block) error {
+	if b != nil && b.Version > g.blockVersion() {
+		return errors.WithDetailf(ErrUnsupportedBlockVersion, "block %d has version %d, supported version is %d", b.Height, b.Version, g.blockVersion())
+	}
+	return nil
+}
+
+// generateBlock is like Chain.GenerateBlock, but it stamps the
+// new block with g's block version, and fails if prev's version
+// is one g doesn't understand.
+func (g *Generator) generateBlock(ctx context.Context, prev *legacy.Block, snapshot *state.Snapshot, t time.Time, txs []*legacy.Tx) (*legacy.Block, *state.Snapshot, error) {
+	err := g.checkBlockVersion(prev)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, s, err := g.chain.GenerateBlock(ctx, prev, snapshot, t, txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Version = g.blockVersion()
+	return b, s, nil
+}
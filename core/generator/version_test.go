@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"context"
+	"testing"
+
+	"chain/errors"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestBlockVersion(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	g := New(c, nil, nil)
+	g.MaxEmptyBlockInterval = 1
+
+	b, s, err := g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if b.Version != 1 {
+		t.Errorf("default block version = %d, want 1", b.Version)
+	}
+
+	g.BlockVersion = 2
+	b, s, err = g.AssembleBlock(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if b.Version != 2 {
+		t.Errorf("block version = %d, want 2", b.Version)
+	}
+
+	g.BlockVersion = 1
+	err = g.commitBlock(ctx, b, s, prottest.Initial(t, c))
+	if errors.Root(err) != ErrUnsupportedBlockVersion {
+		t.Errorf("commitBlock(version 2 block) = %v, want %v", err, ErrUnsupportedBlockVersion)
+	}
+
+	g.tipBlock, g.tipSnapshot = b, s
+	_, _, err = g.AssembleBlock(ctx)
+	if errors.Root(err) != ErrUnsupportedBlockVersion {
+		t.Errorf("AssembleBlock() on version 2 tip = %v, want %v", err, ErrUnsupportedBlockVersion)
+	}
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc/legacy"
+)
+
+// ErrTimeout is returned by SubmitAndWait when the tx isn't
+// committed before its wait time is up.
+var ErrTimeout = errors.New("timed out waiting for transaction to be committed")
+
+// SubmitAndWait submits tx, as Submit does, and waits at most
+// maxWait for it to be committed, returning the height of its
+// block. If it isn't committed in that time, SubmitAndWait returns
+// ErrTimeout, and the tx stays pending. A tx that's dropped as
+// invalid is never committed, so waiting for it times out too.
+func (g *Generator) SubmitAndWait(ctx context.Context, tx *legacy.Tx, maxWait time.Duration) (uint64, error) {
+	var height uint64
+	if latest, _ := g.latest(); latest != nil {
+		height = latest.Height
+	}
+	err := g.Submit(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, maxWait)
+	defer cancel()
+	for {
+		height++
+		b, err := g.WaitForHeight(waitCtx, height)
+		if errors.Root(err) == context.DeadlineExceeded && ctx.Err() == nil {
+			return 0, errors.WithDetailf(ErrTimeout, "transaction %x after %s", tx.ID.Bytes(), maxWait)
+		}
+		if err != nil {
+			return 0, err
+		}
+		for _, btx := range b.Transactions {
+			if btx.ID == tx.ID {
+				return height, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"chain/errors"
+	"chain/protocol/bc/bctest"
+	"chain/protocol/bc/legacy"
+	"chain/protocol/prottest"
+	"chain/testutil"
+)
+
+func TestSubmitAndWait(t *testing.T) {
+	ctx := context.Background()
+	c := prottest.NewChain(t)
+	initial := prottest.Initial(t, c).Hash()
+	g := New(c, nil, nil)
+
+	// Nothing makes blocks, so the tx stays pending.
+	tx := bctest.NewIssuanceTx(t, initial)
+	_, err := g.SubmitAndWait(ctx, tx, 10*time.Millisecond)
+	if errors.Root(err) != ErrTimeout {
+		t.Fatalf("SubmitAndWait() = %v, want %v", err, ErrTimeout)
+	}
+	if pending := g.PendingTxs(); len(pending) != 1 || pending[0].ID != tx.ID {
+		t.Fatalf("pending txs after timeout = %v, want the submitted tx", pending)
+	}
+
+	// Another process commits an empty block, then one with the tx,
+	// once SubmitAndWait has submitted it again.
+	submitted := make(chan struct{})
+	g.TxValidator = func(context.Context, *legacy.Tx) error {
+		close(submitted)
+		return nil
+	}
+	errc := make(chan error, 1)
+	go func() {
+		<-submitted
+		for _, txs := range [][]*legacy.Tx{nil, g.PendingTxs()} {
+			prev, s := c.State()
+			b, s, err := c.GenerateBlock(ctx, prev, s, time.Now(), txs)
+			if err == nil {
+				err = c.CommitAppliedBlock(ctx, b, s)
+			}
+			if err != nil {
+				errc <- err
+				return
+			}
+		}
+		errc <- nil
+	}()
+	height, err := g.SubmitAndWait(ctx, tx, 5*time.Second)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if height != 3 {
+		t.Errorf("SubmitAndWait() height = %d, want 3", height)
+	}
+	if err := <-errc; err != nil {
+		testutil.FatalErr(t, err)
+	}
+}
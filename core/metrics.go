@@ -14,9 +14,11 @@ var (
 	latencies = map[string]*metrics.RotatingLatency{}
 
 	latencyRange = map[string]time.Duration{
-		crosscoreRPCPrefix + "get-block":         20 * time.Second,
-		crosscoreRPCPrefix + "signer/sign-block": 5 * time.Second,
-		crosscoreRPCPrefix + "get-snapshot":      30 * time.Second,
+		crosscoreRPCPrefix + "get-block":                20 * time.Second,
+		crosscoreRPCPrefix + "get-blocks":               20 * time.Second,
+		crosscoreRPCPrefix + "signer/sign-block":        5 * time.Second,
+		crosscoreRPCPrefix + "signer/sign-block-header": 5 * time.Second,
+		crosscoreRPCPrefix + "get-snapshot":             30 * time.Second,
 		// the rest have a default range
 	}
 )
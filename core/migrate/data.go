@@ -81,4 +81,16 @@ var migrations = []migration{
 		ALTER TABLE ONLY core_id
 			ADD CONSTRAINT core_id_pkey PRIMARY KEY (singleton);
 	`},
+	{Name: `2017-07-10.0.generator.tx-hash-index.sql`, SQL: `
+		CREATE INDEX annotated_txs_tx_hash_idx ON annotated_txs USING btree (tx_hash);
+	`},
+	{Name: `2017-07-24.0.generator.leader-epoch.sql`, SQL: `
+		CREATE TABLE generator_epoch (
+			singleton boolean DEFAULT true NOT NULL,
+			epoch bigint NOT NULL,
+			CONSTRAINT generator_epoch_singleton CHECK (singleton)
+		);
+		ALTER TABLE ONLY generator_epoch
+			ADD CONSTRAINT generator_epoch_pkey PRIMARY KEY (singleton);
+	`},
 }
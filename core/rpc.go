@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"chain/core/generator"
+	"chain/core/leader"
 	chainjson "chain/encoding/json"
 	"chain/errors"
 	"chain/net/http/httpjson"
 	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
 )
 
 // getBlockRPC returns the block at the requested height.
@@ -29,6 +32,68 @@ func (a *API) getBlockRPC(ctx context.Context, height uint64) (chainjson.HexByte
 	return rawBlock, nil
 }
 
+type getBlocksReq struct {
+	AfterHeight uint64 `json:"after_height"`
+	Limit       int    `json:"limit"`
+}
+
+// getBlocksRPC returns the blocks after the requested height, in
+// height order, waiting if necessary until there is at least one.
+// It returns at most the requested number of blocks, and never
+// more than generator.DefaultBlocksLimit. It's only available on
+// generators, which can limit concurrent block reads with
+// MaxConcurrentBlockReads.
+func (a *API) getBlocksRPC(ctx context.Context, req getBlocksReq) ([]*legacy.Block, error) {
+	if a.generator == nil {
+		return nil, errNotFound
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > generator.DefaultBlocksLimit {
+		limit = generator.DefaultBlocksLimit
+	}
+	return a.generator.GetBlocks(ctx, req.AfterHeight, limit)
+}
+
+type makeBlockResp struct {
+	Height uint64  `json:"height"`
+	Hash   bc.Hash `json:"hash"`
+}
+
+// makeBlockRPC makes a new block from the generator's pending
+// transactions immediately, rather than waiting for the next
+// block period. It's only available on generators.
+func (a *API) makeBlockRPC(ctx context.Context) (resp makeBlockResp, err error) {
+	if a.generator == nil {
+		return resp, errNotFound
+	}
+	if a.leader.State() != leader.Leading {
+		err = a.forwardToLeader(ctx, crosscoreRPCPrefix+"generator/make-block", nil, &resp)
+		return resp, err
+	}
+
+	b, err := a.generator.MakeBlock(ctx)
+	if err != nil {
+		return resp, err
+	}
+	resp.Height = b.Height
+	resp.Hash = b.Hash()
+	return resp, nil
+}
+
+// debugConfigRPC returns the generator's effective configuration;
+// see generator.DebugConfig. It's only available on generators.
+func (a *API) debugConfigRPC(ctx context.Context) (map[string]interface{}, error) {
+	if a.generator == nil {
+		return nil, errNotFound
+	}
+	if a.leader.State() != leader.Leading {
+		var resp map[string]interface{}
+		err := a.forwardToLeader(ctx, crosscoreRPCPrefix+"generator/debug-config", nil, &resp)
+		return resp, err
+	}
+	return a.generator.DebugConfig(), nil
+}
+
 type snapshotInfoResp struct {
 	Height       uint64  `json:"height"`
 	Size         uint64  `json:"size"`
@@ -5,6 +5,7 @@ import (
 	"context"
 	"testing"
 
+	"chain/core/generator"
 	"chain/core/txdb"
 	"chain/database/pg/pgtest"
 	"chain/protocol/prottest"
@@ -45,3 +46,34 @@ func TestGetBlock(t *testing.T) {
 		t.Errorf("got=%x, want=%s", block, buf.Bytes())
 	}
 }
+
+func TestGetBlocks(t *testing.T) {
+	_, db := pgtest.NewDB(t, pgtest.SchemaPath)
+	ctx := context.Background()
+	store := txdb.NewStore(db)
+	chain := prottest.NewChain(t, prottest.WithStore(store))
+	prottest.MakeBlock(t, chain, nil)
+	prottest.MakeBlock(t, chain, nil)
+
+	api := &API{chain: chain, store: store}
+	_, err := api.getBlocksRPC(ctx, getBlocksReq{})
+	if err != errNotFound {
+		t.Errorf("get-blocks on a non-generator error = %v, want %v", err, errNotFound)
+	}
+
+	api.generator = generator.New(chain, nil, db)
+	blocks, err := api.getBlocksRPC(ctx, getBlocksReq{AfterHeight: 1, Limit: 1})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 1 || blocks[0].Height != 2 {
+		t.Errorf("get-blocks after 1 with limit 1 returned %d blocks, want only block 2", len(blocks))
+	}
+	blocks, err = api.getBlocksRPC(ctx, getBlocksReq{AfterHeight: 1})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(blocks) != 2 || blocks[1].Height != 3 {
+		t.Errorf("get-blocks after 1 returned %d blocks, want blocks 2 and 3", len(blocks))
+	}
+}
@@ -75,6 +75,15 @@ func BlockSigner(signFn func(context.Context, *legacy.Block) ([]byte, error)) Ru
 	return func(a *API) { a.signer = signFn }
 }
 
+// BlockHeaderSigner configures the Core to use signFn to handle
+// requests to sign a block given only its header, from generators
+// with HeaderOnlySigning set. Such a signer can't validate the
+// block's transactions, so without this option the Core doesn't
+// serve those requests.
+func BlockHeaderSigner(signFn func(context.Context, *legacy.BlockHeader) ([]byte, error)) RunOption {
+	return func(a *API) { a.headerSigner = signFn }
+}
+
 // GeneratorLocal configures the launched Core to run as a Generator.
 func GeneratorLocal(gen *generator.Generator) RunOption {
 	return func(a *API) {
@@ -269,7 +278,12 @@ func (a *API) lead(ctx context.Context) {
 	}
 
 	if a.config.IsGenerator {
-		go a.generator.Generate(ctx, blockPeriod, a.healthSetter("generator"))
+		go func() {
+			err := a.generator.Generate(ctx, blockPeriod, a.healthSetter("generator"))
+			if err != nil {
+				log.Fatalkv(ctx, log.KeyError, err)
+			}
+		}()
 	} else {
 		// Remove the downloading snapshot if there was one. The core
 		// has recovered and will now start syncing blocks.
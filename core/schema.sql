@@ -348,6 +348,14 @@ CREATE TABLE core_id (
 
 
 
+CREATE TABLE generator_epoch (
+    singleton boolean DEFAULT true NOT NULL,
+    epoch bigint NOT NULL,
+    CONSTRAINT generator_epoch_singleton CHECK (singleton)
+);
+
+
+
 CREATE TABLE generator_pending_block (
     singleton boolean DEFAULT true NOT NULL,
     data bytea NOT NULL,
@@ -562,6 +570,11 @@ ALTER TABLE ONLY core_id
 
 
 
+ALTER TABLE ONLY generator_epoch
+    ADD CONSTRAINT generator_epoch_pkey PRIMARY KEY (singleton);
+
+
+
 ALTER TABLE ONLY generator_pending_block
     ADD CONSTRAINT generator_pending_block_pkey PRIMARY KEY (singleton);
 
@@ -648,6 +661,10 @@ CREATE INDEX annotated_txs_data_idx ON annotated_txs USING gin (data jsonb_path_
 
 
 
+CREATE INDEX annotated_txs_tx_hash_idx ON annotated_txs USING btree (tx_hash);
+
+
+
 CREATE INDEX query_blocks_timestamp_idx ON query_blocks USING btree ("timestamp");
 
 
@@ -668,3 +685,5 @@ insert into migrations (filename, hash) values ('2017-04-17.0.core.null-token-ty
 insert into migrations (filename, hash) values ('2017-04-27.0.generator.pending-block-height.sql', 'bfe4fe5eec143e4367a91fd952cb5e3879f1c311f649ec13bfe95b202e94d4ec');
 insert into migrations (filename, hash) values ('2017-05-08.0.core.drop-redundant-indexes.sql', '5140e53b287b058c57ddf361d61cff3d3d1cbc3259a9de413b11574a71d09bec');
 insert into migrations (filename, hash) values ('2017-06-28.0.core.coreid.sql', 'a147b93ba1bf404265efedde066532c937070a87e15123b1d9277daba431ee01');
+insert into migrations (filename, hash) values ('2017-07-10.0.generator.tx-hash-index.sql', 'fcccb200a6befbd28334bf81b6d24cbe12ef3b885abc7423b9d43996ef31b662');
+insert into migrations (filename, hash) values ('2017-07-24.0.generator.leader-epoch.sql', 'dd52d132b7a20ff12c47da367d68eb7acffcbcf8334360dcfaa684ef6792fcfc');
@@ -79,6 +79,18 @@ func (s *Store) GetSnapshot(ctx context.Context, height uint64) ([]byte, error)
 	return getRawSnapshot(ctx, s.db, height)
 }
 
+// GetStateSnapshot returns the state snapshot stored at the provided
+// height. If no snapshot exists at that height, it returns an error
+// wrapping pg.ErrUserInputNotFound.
+func (s *Store) GetStateSnapshot(ctx context.Context, height uint64) (*state.Snapshot, error) {
+	data, err := getRawSnapshot(ctx, s.db, height)
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieving state snapshot blob")
+	}
+	snapshot, err := DecodeSnapshot(data)
+	return snapshot, errors.Wrap(err, "decoding snapshot")
+}
+
 // SaveBlock persists a new block in the database.
 func (s *Store) SaveBlock(ctx context.Context, block *legacy.Block) error {
 	const q = `
@@ -95,6 +107,37 @@ func (s *Store) SaveBlock(ctx context.Context, block *legacy.Block) error {
 	return nil
 }
 
+// SaveBlockAtEpoch is like SaveBlock, but for a generator with a
+// leader epoch: it records epoch in the generator_epoch table and
+// saves block in a single statement, only if no higher epoch has
+// been recorded. It reports whether it did.
+func (s *Store) SaveBlockAtEpoch(ctx context.Context, block *legacy.Block, epoch uint64) (bool, error) {
+	const q = `
+		WITH claim AS (
+			INSERT INTO generator_epoch (epoch) VALUES ($5)
+			ON CONFLICT (singleton) DO UPDATE SET epoch = excluded.epoch
+				WHERE generator_epoch.epoch <= excluded.epoch
+			RETURNING epoch
+		), ins AS (
+			INSERT INTO blocks (block_hash, height, data, header)
+			SELECT $1::bytea, $2::bigint, $3::bytea, $4::bytea FROM claim
+			ON CONFLICT (block_hash) DO NOTHING
+		)
+		SELECT count(*) FROM claim
+	`
+	var n int
+	err := s.db.QueryRowContext(ctx, q, block.Hash(), block.Height, block, &block.BlockHeader, epoch).Scan(&n)
+	if err != nil {
+		return false, errors.Wrap(err, "insert block at epoch")
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	s.cache.add(block)
+	return true, nil
+}
+
 // SaveSnapshot saves a state snapshot to the database.
 func (s *Store) SaveSnapshot(ctx context.Context, height uint64, snapshot *state.Snapshot) error {
 	err := storeStateSnapshot(ctx, s.db, snapshot, height)
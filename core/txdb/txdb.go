@@ -4,11 +4,14 @@ package txdb
 
 import (
 	"context"
+	"encoding/binary"
 	"strconv"
 
 	"chain/database/pg"
 	"chain/errors"
 	"chain/log"
+	"chain/protocol/bc"
+	"chain/protocol/bc/legacy"
 )
 
 func ListenBlocks(ctx context.Context, dbURL string) (<-chan uint64, error) {
@@ -51,3 +54,84 @@ func (s *Store) GetRawBlock(ctx context.Context, height uint64) ([]byte, error)
 	err := s.db.QueryRowContext(ctx, q, height).Scan(&block)
 	return block, errors.Wrap(err, "querying blocks from the db")
 }
+
+// ListBlocks calls fn on each block with height greater than
+// afterHeight, in height order, up to limit blocks if limit is
+// positive. If fn returns an error or ctx is canceled, ListBlocks
+// stops and returns an error wrapping it.
+func (s *Store) ListBlocks(ctx context.Context, afterHeight uint64, limit int, fn func(*legacy.Block) error) error {
+	q := `SELECT data FROM blocks WHERE height > $1 ORDER BY height`
+	args := []interface{}{afterHeight}
+	if limit > 0 {
+		q += ` LIMIT $2`
+		args = append(args, limit)
+	}
+	args = append(args, func(b legacy.Block) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(&b)
+	})
+	err := pg.ForQueryRows(ctx, s.db, q, args...)
+	return errors.Wrap(err, "querying blocks from the db")
+}
+
+// LatestBlocks returns the count most recent blocks, newest first.
+func (s *Store) LatestBlocks(ctx context.Context, count int) ([]*legacy.Block, error) {
+	const q = `SELECT data FROM blocks ORDER BY height DESC LIMIT $1`
+	var blocks []*legacy.Block
+	err := pg.ForQueryRows(ctx, s.db, q, count, func(b legacy.Block) {
+		blocks = append(blocks, &b)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "querying blocks from the db")
+	}
+	return blocks, nil
+}
+
+// GetBlockByHash looks up the block with the provided hash.
+// If there is none, it returns an error that wraps sql.ErrNoRows.
+func (s *Store) GetBlockByHash(ctx context.Context, hash bc.Hash) (*legacy.Block, error) {
+	const q = `SELECT data FROM blocks WHERE block_hash = $1`
+	var b legacy.Block
+	err := s.db.QueryRowContext(ctx, q, hash).Scan(&b)
+	if err != nil {
+		return nil, errors.Wrap(err, "querying blocks from the db")
+	}
+	return &b, nil
+}
+
+// ListBlockHeaders calls fn with the header, serialized size and
+// number of transactions of each block with height greater than
+// afterHeight, in height order, up to limit blocks. It reads only
+// the headers and the start of the transactions from the database,
+// which is much cheaper than reading and decoding whole blocks.
+func (s *Store) ListBlockHeaders(ctx context.Context, afterHeight uint64, limit int, fn func(header *legacy.BlockHeader, size int, txCount uint64) error) error {
+	// A serialized block is its header, then the number of
+	// transactions as a varint of at most 5 bytes, then the
+	// transactions.
+	const q = `
+		SELECT header, length(data), substring(data FROM length(header) + 1 FOR 5)
+		FROM blocks WHERE height > $1 ORDER BY height LIMIT $2
+	`
+	err := pg.ForQueryRows(ctx, s.db, q, afterHeight, limit, func(header legacy.BlockHeader, size int, count []byte) error {
+		n, err := decodeTxCount(count)
+		if err != nil {
+			return errors.Wrapf(err, "block %d", header.Height)
+		}
+		return fn(&header, size, n)
+	})
+	return errors.Wrap(err, "querying block headers from the db")
+}
+
+var errBadTxCount = errors.New("bad transaction count in stored block")
+
+// decodeTxCount decodes the number of transactions from the
+// bytes of a serialized block that follow its header.
+func decodeTxCount(b []byte) (uint64, error) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 {
+		return 0, errBadTxCount
+	}
+	return n, nil
+}
@@ -3,9 +3,12 @@ package txdb
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"testing"
 
+	"chain/database/pg"
 	"chain/database/pg/pgtest"
+	"chain/errors"
 	"chain/protocol/bc"
 	"chain/protocol/bc/legacy"
 	"chain/protocol/state"
@@ -62,6 +65,18 @@ func TestLatestSnapshot(t *testing.T) {
 	if !testutil.DeepEqual(decoded, snap) {
 		t.Errorf("GetSnapshot got %#v, want %#v", decoded, snap)
 	}
+	// Check that GetStateSnapshot decodes the same snapshot.
+	got, err = store.GetStateSnapshot(ctx, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !testutil.DeepEqual(got, snap) {
+		t.Errorf("GetStateSnapshot got %#v, want %#v", got, snap)
+	}
+	_, err = store.GetStateSnapshot(ctx, height+1)
+	if errors.Root(err) != pg.ErrUserInputNotFound {
+		t.Errorf("GetStateSnapshot at height %d error = %v, want %v", height+1, err, pg.ErrUserInputNotFound)
+	}
 }
 
 func TestGetRawBlock(t *testing.T) {
@@ -201,3 +216,100 @@ func TestInsertBlock(t *testing.T) {
 		t.Errorf("got %#v, wanted %#v", got, blk)
 	}
 }
+
+func TestDecodeTxCount(t *testing.T) {
+	blk := &legacy.Block{
+		BlockHeader: legacy.BlockHeader{Version: 1, Height: 2},
+		Transactions: []*legacy.Tx{
+			legacy.NewTx(legacy.TxData{ReferenceData: []byte("a")}),
+			legacy.NewTx(legacy.TxData{ReferenceData: []byte("b")}),
+		},
+	}
+	var data, header bytes.Buffer
+	_, err := blk.WriteTo(&data)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	_, err = blk.BlockHeader.WriteTo(&header)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	n, err := decodeTxCount(data.Bytes()[header.Len() : header.Len()+5])
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if n != 2 {
+		t.Errorf("decodeTxCount() = %d, want 2", n)
+	}
+
+	_, err = decodeTxCount([]byte{0x80})
+	if err != errBadTxCount {
+		t.Errorf("decodeTxCount of a truncated varint error = %v, want %v", err, errBadTxCount)
+	}
+}
+
+func TestBlockReaders(t *testing.T) {
+	ctx := context.Background()
+	dbtx := pgtest.NewTx(t)
+	store := NewStore(dbtx)
+	var blocks []*legacy.Block
+	for h := uint64(1); h <= 3; h++ {
+		b := &legacy.Block{
+			BlockHeader:  legacy.BlockHeader{Version: 1, Height: h},
+			Transactions: make([]*legacy.Tx, h-1),
+		}
+		for i := range b.Transactions {
+			b.Transactions[i] = legacy.NewTx(legacy.TxData{ReferenceData: []byte{byte(h), byte(i)}})
+		}
+		err := store.SaveBlock(ctx, b)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		blocks = append(blocks, b)
+	}
+
+	var got []uint64
+	err := store.ListBlocks(ctx, 1, 0, func(b *legacy.Block) error {
+		got = append(got, b.Height)
+		return nil
+	})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !testutil.DeepEqual(got, []uint64{2, 3}) {
+		t.Errorf("ListBlocks(1, 0) heights = %v, want [2 3]", got)
+	}
+
+	latest, err := store.LatestBlocks(ctx, 2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if len(latest) != 2 || latest[0].Height != 3 || latest[1].Height != 2 {
+		t.Errorf("LatestBlocks(2) returned %d blocks, want blocks 3 and 2", len(latest))
+	}
+
+	b, err := store.GetBlockByHash(ctx, blocks[1].Hash())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if b.Hash() != blocks[1].Hash() {
+		t.Errorf("GetBlockByHash returned block %d, want 2", b.Height)
+	}
+	_, err = store.GetBlockByHash(ctx, bc.Hash{})
+	if errors.Root(err) != sql.ErrNoRows {
+		t.Errorf("GetBlockByHash of a missing block error = %v, want %v", err, sql.ErrNoRows)
+	}
+
+	var counts []uint64
+	err = store.ListBlockHeaders(ctx, 0, 10, func(header *legacy.BlockHeader, size int, txCount uint64) error {
+		counts = append(counts, txCount)
+		return nil
+	})
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if !testutil.DeepEqual(counts, []uint64{0, 1, 2}) {
+		t.Errorf("ListBlockHeaders tx counts = %v, want [0 1 2]", counts)
+	}
+}
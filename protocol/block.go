@@ -41,6 +41,9 @@ func (c *Chain) GetBlock(ctx context.Context, height uint64) (*legacy.Block, err
 // GenerateBlock generates a valid, but unsigned, candidate block from
 // the current pending transaction pool. It returns the new block and
 // a snapshot of what the state snapshot is if the block is applied.
+// The block follows prev, and its changes are applied to a copy of
+// snapshot, the state as of prev, even if the chain's own latest
+// block is a different one.
 //
 // After generating the block, the pending transaction pool will be
 // empty.
@@ -55,7 +58,7 @@ func (c *Chain) GenerateBlock(ctx context.Context, prev *legacy.Block, snapshot
 	}
 
 	// Make a copy of the snapshot that we can apply our changes to.
-	newSnapshot := state.Copy(c.state.snapshot)
+	newSnapshot := state.Copy(snapshot)
 	newSnapshot.PruneNonces(timestampMS)
 
 	b := &legacy.Block{
@@ -214,6 +217,16 @@ func (c *Chain) queueSnapshot(ctx context.Context, height uint64, timestamp time
 	}
 }
 
+// SaveSnapshot saves s, the state snapshot as of the block at
+// height, to persistent storage, and returns when it's done.
+// Chain already saves snapshots periodically on its own;
+// SaveSnapshot is for callers that need one saved at a
+// particular height.
+func (c *Chain) SaveSnapshot(ctx context.Context, height uint64, s *state.Snapshot) error {
+	err := c.store.SaveSnapshot(ctx, height, s)
+	return errors.Wrap(err, "saving snapshot")
+}
+
 // ValidateBlockForSig performs validation on an incoming _unsigned_
 // block in preparation for signing it. By definition it does not
 // execute the consensus program.
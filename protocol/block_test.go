@@ -195,6 +195,88 @@ func TestGenerateBlock(t *testing.T) {
 	}
 }
 
+func TestGenerateBlockSnapshot(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c, b1 := newTestChain(t, now)
+
+	// Move the chain's own state past b1, to a state that isn't empty.
+	b2, s2, err := c.GenerateBlock(ctx, b1, state.Empty(), now.Add(time.Second), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = s2.Tree.Insert(bc.NewHash([32]byte{1}).Bytes())
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	err = c.CommitAppliedBlock(ctx, b2, s2)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+
+	// A block built on b1 starts from the given state, not the chain's.
+	b, s, err := c.GenerateBlock(ctx, b1, state.Empty(), now.Add(time.Second), nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if want := state.Empty().Tree.RootHash(); s.Tree.RootHash() != want || b.AssetsMerkleRoot != want {
+		t.Errorf("block on b1 has assets root %x, want the empty state's %x", b.AssetsMerkleRoot.Bytes(), want.Bytes())
+	}
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	c, b1 := newTestChain(t, now)
+
+	// Wait for the snapshot queued by b1, so it
+	// can't land after the one saved below.
+	for {
+		_, height, _ := c.store.LatestSnapshot(ctx)
+		if height > 0 {
+			break
+		}
+	}
+
+	b, s := b1, state.Empty()
+	for i := 0; i < 2; i++ {
+		tx, _, _ := issue(t, nil, nil, 1)
+		var err error
+		b, s, err = c.GenerateBlock(ctx, b, s, now.Add(time.Duration(i+1)*time.Second), []*legacy.Tx{tx})
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+		err = c.CommitAppliedBlock(ctx, b, s)
+		if err != nil {
+			testutil.FatalErr(t, err)
+		}
+	}
+
+	err := c.SaveSnapshot(ctx, b.Height, s)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	saved, height, err := c.store.LatestSnapshot(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if height != b.Height || saved.Tree.RootHash() != s.Tree.RootHash() {
+		t.Errorf("latest snapshot is at height %d with root %x, want %d, %x", height, saved.Tree.RootHash().Bytes(), b.Height, s.Tree.RootHash().Bytes())
+	}
+
+	c2, err := NewChain(ctx, b1.Hash(), c.store, nil)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	gotBlock, gotSnapshot, err := c2.Recover(ctx)
+	if err != nil {
+		testutil.FatalErr(t, err)
+	}
+	if gotBlock.Hash() != b.Hash() || gotSnapshot.Tree.RootHash() != s.Tree.RootHash() {
+		t.Errorf("Recover() = block %d, root %x, want block %d, root %x", gotBlock.Height, gotSnapshot.Tree.RootHash().Bytes(), b.Height, s.Tree.RootHash().Bytes())
+	}
+}
+
 func TestValidateBlockForSig(t *testing.T) {
 	initialBlock, err := NewInitialBlock(testutil.TestPubs, 1, time.Now())
 	if err != nil {